@@ -0,0 +1,100 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"sync"
+
+	"github.com/souravbiswassanto/high-write-load-client/ledger"
+)
+
+// ackTracker keeps every acknowledged write in memory for the end-of-run
+// data loss check and mirrors it to the on-disk ledger when one is configured
+type ackTracker struct {
+	mu       sync.Mutex
+	inserts  []ledger.Entry
+	inDoubt  []ledger.Entry
+	versions map[int64]int64
+	ledger   *ledger.Writer
+	runID    string // Stamped on every entry
+}
+
+// newAckTracker creates a tracker for the given run; w may be nil to keep
+// writes in memory only
+func newAckTracker(w *ledger.Writer, runID string) *ackTracker {
+	return &ackTracker{
+		inserts:  make([]ledger.Entry, 0),
+		inDoubt:  make([]ledger.Entry, 0),
+		versions: make(map[int64]int64),
+		ledger:   w,
+		runID:    runID,
+	}
+}
+
+// Record stores an acknowledged write. The ledger is written first so that
+// whatever the end-of-run check sees is also durable on disk.
+func (t *ackTracker) Record(e ledger.Entry) error {
+	e.RunID = t.runID
+	if t.ledger != nil {
+		if err := t.ledger.Append(e); err != nil {
+			return err
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch e.Kind {
+	case ledger.KindInsert:
+		t.inserts = append(t.inserts, e)
+	case ledger.KindUpdate:
+		for i, id := range e.IDs {
+			if i < len(e.Versions) && e.Versions[i] > t.versions[id] {
+				t.versions[id] = e.Versions[i]
+			}
+		}
+	case ledger.KindInDoubt:
+		t.inDoubt = append(t.inDoubt, e)
+	}
+	return nil
+}
+
+// AckedWrites returns a copy of everything acknowledged or left in doubt so far
+func (t *ackTracker) AckedWrites() *AckedWrites {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	acked := &AckedWrites{
+		Inserts:         make([]ledger.Entry, len(t.inserts)),
+		InDoubt:         make([]ledger.Entry, len(t.inDoubt)),
+		UpdatedVersions: make(map[int64]int64, len(t.versions)),
+	}
+	copy(acked.Inserts, t.inserts)
+	copy(acked.InDoubt, t.inDoubt)
+	for id, version := range t.versions {
+		acked.UpdatedVersions[id] = version
+	}
+	return acked
+}
+
+// Close closes the underlying ledger, if any
+func (t *ackTracker) Close() error {
+	if t.ledger == nil {
+		return nil
+	}
+	return t.ledger.Close()
+}
@@ -0,0 +1,132 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/souravbiswassanto/high-write-load-client/config"
+	"github.com/souravbiswassanto/high-write-load-client/metrics"
+)
+
+// workerGate lets only the first n workers issue operations; the others wait
+// until n grows past their ID. All CONCURRENT_WRITERS goroutines are started
+// up front, so changing n is cheap.
+type workerGate struct {
+	active atomic.Int32
+
+	mu      sync.Mutex
+	changed chan struct{} // Closed and replaced whenever active changes
+}
+
+// newWorkerGate creates a gate admitting n workers
+func newWorkerGate(n int) *workerGate {
+	g := &workerGate{changed: make(chan struct{})}
+	g.active.Store(int32(n))
+	return g
+}
+
+// set admits n workers
+func (g *workerGate) set(n int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.active.Swap(int32(n)) != int32(n) {
+		close(g.changed)
+		g.changed = make(chan struct{})
+	}
+}
+
+// wait blocks until the worker is admitted. It returns false when ctx is
+// done or stop is closed first.
+func (g *workerGate) wait(ctx context.Context, stop <-chan struct{}, workerID int) bool {
+	if workerID < int(g.active.Load()) {
+		return true
+	}
+	for {
+		g.mu.Lock()
+		admitted := workerID < int(g.active.Load())
+		changed := g.changed
+		g.mu.Unlock()
+		if admitted {
+			return true
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return false
+		case <-stop:
+			return false
+		}
+	}
+}
+
+// aimd computes the next worker count: additive increase after an interval
+// within the latency and error targets, multiplicative decrease otherwise
+func aimd(cfg config.AdaptiveConfig, maxWorkers, workers int, window metrics.Progress) (int, string) {
+	if window.Operations+window.Errors == 0 {
+		return workers, ""
+	}
+	if p99 := window.WorstP99(); p99 > cfg.TargetP99 {
+		return max(int(float64(workers)*cfg.DecreaseFactor), cfg.MinWorkers),
+			fmt.Sprintf("p99 %v above %v", p99.Round(time.Microsecond), cfg.TargetP99)
+	}
+	if errorPercent := window.ErrorPercent(); errorPercent > cfg.MaxErrorPercent {
+		return max(int(float64(workers)*cfg.DecreaseFactor), cfg.MinWorkers),
+			fmt.Sprintf("error rate %.2f%% above %.2f%%", errorPercent, cfg.MaxErrorPercent)
+	}
+	return min(workers+cfg.Increase, maxWorkers), ""
+}
+
+// adaptConcurrency adjusts the number of active workers every interval
+// until the load generator stops
+func (lg *LoadGeneratorV2) adaptConcurrency(ctx context.Context) {
+	defer lg.wg.Done()
+
+	cfg := lg.config.Adaptive
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	workers := cfg.InitialWorkers
+	last := lg.metrics.Progress()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-lg.stopChan:
+			return
+		case <-ticker.C:
+		}
+
+		now := lg.metrics.Progress()
+		next, reason := aimd(cfg, lg.config.Load.ConcurrentWriters, workers, now.Since(last))
+		last = now
+		if next == workers {
+			continue
+		}
+		if next < workers {
+			fmt.Printf("Adaptive concurrency: %s, reducing workers from %d to %d\n", reason, workers, next)
+		}
+		workers = next
+		lg.gate.set(workers)
+		lg.metrics.UpdateActiveWorkers(workers)
+	}
+}
@@ -0,0 +1,142 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/souravbiswassanto/high-write-load-client/metrics"
+)
+
+// backpressure keeps DB_MIN_FREE_CONNS server connections free for other
+// clients during the run. Whenever the connection monitor sees fewer free
+// connections, the write pool's connection cap is lowered by the shortfall,
+// which makes workers queue for a connection; once there is nothing left to
+// give up, workers are paused. As connections free up, the cap grows back by
+// the surplus until the configured pool size is restored.
+type backpressure struct {
+	minFree int
+	maxOpen int // Uncapped size of the write pool
+
+	mu        sync.Mutex
+	limit     int           // Current cap; 0 pauses the workers
+	resume    chan struct{} // Closed while workers may run
+	pausedAt  time.Time
+	throttled int64         // Times the cap was lowered from the full pool size
+	pausedFor time.Duration // Total time paused, excluding an ongoing pause
+}
+
+// newBackpressure creates an inactive controller for a pool of maxOpen connections
+func newBackpressure(minFree, maxOpen int) *backpressure {
+	resume := make(chan struct{})
+	close(resume)
+	return &backpressure{
+		minFree: minFree,
+		maxOpen: maxOpen,
+		limit:   maxOpen,
+		resume:  resume,
+	}
+}
+
+// update adjusts the cap to the number of free server connections and
+// returns the new cap and whether it changed
+func (b *backpressure) update(available int) (int, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	prev := b.limit
+	if short := b.minFree - available; short > 0 {
+		b.limit = max(b.limit-short, 0)
+	} else {
+		b.limit = min(b.limit+available-b.minFree, b.maxOpen)
+	}
+
+	if prev == b.maxOpen && b.limit < prev {
+		b.throttled++
+	}
+	switch {
+	case prev > 0 && b.limit == 0:
+		b.resume = make(chan struct{})
+		b.pausedAt = time.Now()
+	case prev == 0 && b.limit > 0:
+		close(b.resume)
+		b.pausedFor += time.Since(b.pausedAt)
+	}
+	return b.limit, b.limit != prev
+}
+
+// wait blocks while the workers are paused. It returns false when ctx is
+// done or stop is closed first.
+func (b *backpressure) wait(ctx context.Context, stop <-chan struct{}) bool {
+	b.mu.Lock()
+	resume := b.resume
+	b.mu.Unlock()
+
+	select {
+	case <-resume:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-stop:
+		return false
+	}
+}
+
+// snapshot returns the controller's state for the metrics
+func (b *backpressure) snapshot() metrics.BackpressureSnapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := metrics.BackpressureSnapshot{
+		Limit:     b.limit,
+		Max:       b.maxOpen,
+		Paused:    b.limit == 0,
+		Throttled: b.throttled,
+		PausedFor: b.pausedFor,
+	}
+	if s.Paused {
+		s.PausedFor += time.Since(b.pausedAt)
+	}
+	return s
+}
+
+// ApplyBackpressure throttles or pauses the workers when the server has
+// fewer than DB_MIN_FREE_CONNS connections free, and lifts the throttle as
+// connections free up. It is meant to be fed by MonitorConnections.
+func (lg *LoadGeneratorV2) ApplyBackpressure(stats *ConnectionStats) {
+	limit, changed := lg.backpressure.update(int(stats.AvailableConnections))
+	lg.metrics.UpdateBackpressure(lg.backpressure.snapshot())
+	if !changed {
+		return
+	}
+
+	free := fmt.Sprintf("%d server connections free, minimum %d", stats.AvailableConnections, lg.config.DB.MinFreeConns)
+	switch {
+	case limit == 0:
+		fmt.Printf("Backpressure: %s, pausing workers\n", free)
+	case limit == lg.backpressure.maxOpen:
+		lg.cm.LimitWriteConns(0)
+		fmt.Printf("Backpressure: %s, lifted\n", free)
+	default:
+		lg.cm.LimitWriteConns(limit)
+		fmt.Printf("Backpressure: %s, write pool limited to %d of %d connections\n",
+			free, limit, lg.backpressure.maxOpen)
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/souravbiswassanto/high-write-load-client/config"
+)
+
+// keyGenerator issues the client keys for the rows written by one worker.
+// It is not safe for concurrent use; every worker owns its own generator.
+type keyGenerator struct {
+	format string
+	prefix string // Run ID and worker, for the worker-seq format
+	seq    int64
+}
+
+// newKeyGenerator creates a generator for the given worker. worker is only
+// used to keep worker-seq keys unique, e.g. "seed" for the initial rows.
+func newKeyGenerator(format, runID, worker string) *keyGenerator {
+	return &keyGenerator{
+		format: format,
+		prefix: runID + "/" + worker + "/",
+	}
+}
+
+// Next returns a new key, or "" when client keys are disabled
+func (g *keyGenerator) Next() string {
+	switch g.format {
+	case config.ClientKeysUUIDv7:
+		// UUIDv7 only fails if the system random source does
+		if id, err := uuid.NewV7(); err == nil {
+			return id.String()
+		}
+		fallthrough
+	case config.ClientKeysWorkerSeq:
+		g.seq++
+		return fmt.Sprintf("%s%d", g.prefix, g.seq)
+	default:
+		return ""
+	}
+}
+
+// clientKeyArg returns the value to store in the client_key column; rows
+// written without a key get NULL so they never look like duplicates
+func clientKeyArg(key string) interface{} {
+	if key == "" {
+		return nil
+	}
+	return key
+}
@@ -20,6 +20,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	_ "github.com/lib/pq" // PostgreSQL driver
@@ -27,10 +30,36 @@ import (
 	"k8s.io/klog/v2"
 )
 
-// ConnectionManager manages PostgreSQL connections with safety checks
+// WritePoolName is the name of the pool connected to the current primary, which takes all writes
+const WritePoolName = "primary"
+
+// rediscoveryInterval is the minimum time between two checks of the current host
+const rediscoveryInterval = time.Second
+
+// ConnectionManager manages PostgreSQL connections with safety checks.
+// Writes go through the pool connected to the host chosen from DB_HOST by the
+// target session attributes; reads can be spread over separate pools
+// connected to standbys. When statements fail, the chosen host is re-probed
+// and writes are moved to whichever host matches now, e.g. a new primary.
 type ConnectionManager struct {
-	db     *sql.DB
-	config *config.DBConfig
+	db        atomic.Pointer[sql.DB]
+	readPools []Pool
+	config    *config.DBConfig
+
+	mu        sync.Mutex
+	host      string                // Host the write pool is connected to
+	onSwitch  func(from, to string) // Called after writes move to another host
+	probing   atomic.Bool
+	lastProbe time.Time // Only touched while probing is set
+
+	// Connection cap of the write pool set by backpressure; 0 means the configured size
+	writeLimit atomic.Int32
+}
+
+// Pool is a named connection pool
+type Pool struct {
+	Name string
+	DB   *sql.DB
 }
 
 // ConnectionStats represents the current connection state
@@ -47,24 +76,20 @@ func NewConnectionManager(cfg *config.DBConfig) (*ConnectionManager, error) {
 		config: cfg,
 	}
 
-	// First, create a connection to check max_connections
-	connStr := cfg.GetConnectionString()
-	klog.Infoln("Connecting to database with connection string:", connStr)
-	db, err := sql.Open("postgres", connStr)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open database connection: %w", err)
-	}
-
-	// Test the connection with a longer timeout for Kubernetes networking
+	// Test the connections with a longer timeout for Kubernetes networking
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	if err := db.PingContext(ctx); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+	// First, find a host matching the target session attributes
+	klog.Infof("Connecting to database on %s (target_session_attrs=%s)",
+		strings.Join(cfg.Hosts, ","), cfg.TargetSessionAttrs)
+	db, host, err := cm.connect(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	cm.db = db
+	cm.db.Store(db)
+	cm.host = host
 
 	// Check if we can safely connect
 	stats, err := cm.GetConnectionStats(ctx)
@@ -85,27 +110,216 @@ func NewConnectionManager(cfg *config.DBConfig) (*ConnectionManager, error) {
 	}
 
 	// Configure connection pool
-	db.SetMaxOpenConns(cfg.MaxOpenConns)
-	db.SetMaxIdleConns(cfg.MaxIdleConns)
-	db.SetConnMaxLifetime(time.Hour)
-	db.SetConnMaxIdleTime(15 * time.Minute)
+	cm.configurePool(db)
 
 	fmt.Printf("Connection Manager initialized successfully\n")
+	fmt.Printf("  Connected to: %s\n", host)
 	fmt.Printf("  Max connections in DB: %d\n", stats.MaxConnections)
 	fmt.Printf("  Current active connections: %d\n", stats.CurrentConnections)
 	fmt.Printf("  Available connections: %d\n", stats.AvailableConnections)
 	fmt.Printf("  Client pool size: %d (max open), %d (max idle)\n", cfg.MaxOpenConns, cfg.MaxIdleConns)
 
+	for _, host := range cfg.ReadHosts {
+		pool, err := cm.openReadPool(ctx, host)
+		if err != nil {
+			cm.Close()
+			return nil, err
+		}
+		cm.readPools = append(cm.readPools, pool)
+	}
+
 	return cm, nil
 }
 
+// configurePool applies the configured pool sizing to db
+func (cm *ConnectionManager) configurePool(db *sql.DB) {
+	db.SetMaxOpenConns(cm.config.MaxOpenConns)
+	db.SetMaxIdleConns(cm.config.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Hour)
+	db.SetConnMaxIdleTime(15 * time.Minute)
+}
+
+// LimitWriteConns caps the write pool at n connections, or restores the
+// configured size when n is 0. The cap follows the writes to a new primary.
+func (cm *ConnectionManager) LimitWriteConns(n int) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.writeLimit.Store(int32(n))
+	cm.applyWriteLimit(cm.GetDB())
+}
+
+// applyWriteLimit applies the backpressure cap, if any, to the write pool db
+func (cm *ConnectionManager) applyWriteLimit(db *sql.DB) {
+	limit := int(cm.writeLimit.Load())
+	if limit == 0 {
+		// Lowering the open limit also lowered the idle limit; restore both
+		cm.configurePool(db)
+		return
+	}
+	db.SetMaxOpenConns(limit)
+}
+
+// connect tries the configured hosts in order and returns an open pool to the
+// first one matching the target session attributes
+func (cm *ConnectionManager) connect(ctx context.Context) (*sql.DB, string, error) {
+	var lastErr error
+
+	for _, host := range cm.config.Hosts {
+		db, inRecovery, err := cm.probe(ctx, host)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if cm.matches(inRecovery) {
+			return db, host, nil
+		}
+		db.Close()
+	}
+
+	if lastErr != nil {
+		return nil, "", fmt.Errorf("no host matches target_session_attrs=%s: %w", cm.config.TargetSessionAttrs, lastErr)
+	}
+	return nil, "", fmt.Errorf("no host matches target_session_attrs=%s", cm.config.TargetSessionAttrs)
+}
+
+// probe opens a pool to host and reports whether the server is in recovery
+func (cm *ConnectionManager) probe(ctx context.Context, host string) (*sql.DB, bool, error) {
+	hostCfg := cm.config.ForHost(host)
+	db, err := sql.Open("postgres", hostCfg.GetConnectionString())
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open database connection to %s: %w", host, err)
+	}
+
+	var inRecovery bool
+	if err := db.QueryRowContext(ctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery); err != nil {
+		db.Close()
+		return nil, false, fmt.Errorf("failed to probe %s: %w", host, err)
+	}
+	return db, inRecovery, nil
+}
+
+// matches reports whether a server in the given recovery state satisfies the
+// target session attributes
+func (cm *ConnectionManager) matches(inRecovery bool) bool {
+	switch cm.config.TargetSessionAttrs {
+	case config.TargetReadWrite:
+		return !inRecovery
+	default:
+		return true
+	}
+}
+
+// OnSwitch registers a callback invoked after writes move to another host
+func (cm *ConnectionManager) OnSwitch(fn func(from, to string)) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.onSwitch = fn
+}
+
+// CurrentHost returns the host the write pool is connected to
+func (cm *ConnectionManager) CurrentHost() string {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	return cm.host
+}
+
+// ReportError tells the manager that a statement on the write pool failed.
+// Unless a check is already running or ran within rediscoveryInterval, the
+// current host is re-probed in the background and writes are switched to
+// another host if it no longer matches the target session attributes.
+func (cm *ConnectionManager) ReportError(err error) {
+	if err == nil || len(cm.config.Hosts) < 2 {
+		return
+	}
+	if !cm.probing.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer cm.probing.Store(false)
+		if time.Since(cm.lastProbe) < rediscoveryInterval {
+			return
+		}
+		cm.lastProbe = time.Now()
+		cm.rediscover()
+	}()
+}
+
+// rediscover switches the write pool to a matching host when the current one no longer matches
+func (cm *ConnectionManager) rediscover() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var inRecovery bool
+	err := cm.GetDB().QueryRowContext(ctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery)
+	if err == nil && cm.matches(inRecovery) {
+		return
+	}
+
+	db, host, err := cm.connect(ctx)
+	if err != nil {
+		klog.Warningf("Primary rediscovery failed: %v", err)
+		return
+	}
+
+	cm.mu.Lock()
+	from := cm.host
+	if host == from {
+		cm.mu.Unlock()
+		db.Close()
+		return
+	}
+	cm.configurePool(db)
+	cm.applyWriteLimit(db)
+	old := cm.db.Swap(db)
+	cm.host = host
+	onSwitch := cm.onSwitch
+	cm.mu.Unlock()
+
+	fmt.Printf("Switched writes from %s to %s (target_session_attrs=%s)\n", from, host, cm.config.TargetSessionAttrs)
+	if onSwitch != nil {
+		onSwitch(from, host)
+	}
+
+	// Close waits for statements still running on the old host
+	go old.Close()
+}
+
+// openReadPool opens a pool to a read host with the same credentials and pool sizing
+func (cm *ConnectionManager) openReadPool(ctx context.Context, host string) (Pool, error) {
+	readCfg := cm.config.ForHost(host)
+
+	db, err := sql.Open("postgres", readCfg.GetConnectionString())
+	if err != nil {
+		return Pool{}, fmt.Errorf("failed to open read pool %s: %w", host, err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return Pool{}, fmt.Errorf("failed to ping read pool %s: %w", host, err)
+	}
+
+	var inRecovery bool
+	if err := db.QueryRowContext(ctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery); err != nil {
+		db.Close()
+		return Pool{}, fmt.Errorf("failed to check recovery state of %s: %w", host, err)
+	}
+
+	cm.configurePool(db)
+
+	role := "standby"
+	if !inRecovery {
+		role = "primary (not a standby)"
+	}
+	fmt.Printf("  Read pool %s: %s\n", host, role)
+	return Pool{Name: host, DB: db}, nil
+}
+
 // GetConnectionStats retrieves current connection statistics from PostgreSQL
 func (cm *ConnectionManager) GetConnectionStats(ctx context.Context) (*ConnectionStats, error) {
 	stats := &ConnectionStats{}
 
 	// Get max_connections setting
 	var maxConns int32
-	err := cm.db.QueryRowContext(ctx, "SHOW max_connections").Scan(&maxConns)
+	err := cm.GetDB().QueryRowContext(ctx, "SHOW max_connections").Scan(&maxConns)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get max_connections: %w", err)
 	}
@@ -114,7 +328,7 @@ func (cm *ConnectionManager) GetConnectionStats(ctx context.Context) (*Connectio
 	// Get current number of active connections
 	var currentConns int32
 	query := `SELECT count(*) FROM pg_stat_activity WHERE state != 'idle' OR state IS NULL`
-	err = cm.db.QueryRowContext(ctx, query).Scan(&currentConns)
+	err = cm.GetDB().QueryRowContext(ctx, query).Scan(&currentConns)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current connections: %w", err)
 	}
@@ -129,22 +343,44 @@ func (cm *ConnectionManager) GetConnectionStats(ctx context.Context) (*Connectio
 	return stats, nil
 }
 
+// ServerVersion returns the server_version of the current primary
+func (cm *ConnectionManager) ServerVersion(ctx context.Context) (string, error) {
+	var version string
+	if err := cm.GetDB().QueryRowContext(ctx, "SHOW server_version").Scan(&version); err != nil {
+		return "", fmt.Errorf("failed to get server version: %w", err)
+	}
+	return version, nil
+}
+
 // GetDB returns the underlying database connection
 func (cm *ConnectionManager) GetDB() *sql.DB {
-	return cm.db
+	return cm.db.Load()
 }
 
-// Close closes the database connection
+// ReadPools returns the pools connected to read hosts; empty when reads go to the primary
+func (cm *ConnectionManager) ReadPools() []Pool {
+	return cm.readPools
+}
+
+// Pools returns the write pool followed by every read pool
+func (cm *ConnectionManager) Pools() []Pool {
+	return append([]Pool{{Name: WritePoolName, DB: cm.GetDB()}}, cm.readPools...)
+}
+
+// Close closes every database connection
 func (cm *ConnectionManager) Close() error {
-	if cm.db != nil {
-		return cm.db.Close()
+	for _, pool := range cm.readPools {
+		pool.DB.Close()
+	}
+	if db := cm.db.Load(); db != nil {
+		return db.Close()
 	}
 	return nil
 }
 
 // GetDBStats returns database/sql connection pool stats
 func (cm *ConnectionManager) GetDBStats() sql.DBStats {
-	return cm.db.Stats()
+	return cm.GetDB().Stats()
 }
 
 // MonitorConnections periodically monitors connection stats
@@ -170,7 +406,7 @@ func (cm *ConnectionManager) MonitorConnections(ctx context.Context, interval ti
 // HealthCheck performs a health check on the connection
 func (cm *ConnectionManager) HealthCheck(ctx context.Context) error {
 	// Simple ping
-	if err := cm.db.PingContext(ctx); err != nil {
+	if err := cm.GetDB().PingContext(ctx); err != nil {
 		return fmt.Errorf("database ping failed: %w", err)
 	}
 
@@ -0,0 +1,84 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// workerSession is the state one worker carries from operation to operation
+type workerSession struct {
+	keys *keyGenerator
+
+	// The worker's newest acknowledged insert, which its replica reads expect to
+	// see, and the highest ID any of those reads has seen so far
+	lastAckedID int64
+	lastAckedAt time.Time
+	maxSeenID   int64
+}
+
+// publishAck makes an acknowledged insert the session's latest write
+func (s *workerSession) publishAck(ids []int64, ackedAt time.Time) {
+	for _, id := range ids {
+		if id > s.lastAckedID {
+			s.lastAckedID = id
+			s.lastAckedAt = ackedAt
+		}
+	}
+}
+
+// performConsistencyRead reads from a random read pool and checks that it
+// sees the session's latest acknowledged insert (read-your-writes) and no
+// fewer rows than any earlier read of the session (monotonic reads). Like
+// other reads it is retried, and counted towards the pool that served it.
+func (lg *LoadGeneratorV2) performConsistencyRead(ctx context.Context, rng *rand.Rand, s *workerSession, intended time.Time) {
+	query := fmt.Sprintf(`
+		SELECT COALESCE((SELECT max(id) FROM %s), 0),
+		       EXISTS (SELECT 1 FROM %s WHERE id = $1)
+	`, lg.tableName, lg.tableName)
+
+	var pool Pool
+	var start time.Time
+	var maxID int64
+	var visible bool
+	err := lg.withRetries(ctx, rng, opRead, func() error {
+		pool = lg.readPool(rng)
+		start = time.Now()
+		err := pool.DB.QueryRowContext(ctx, query, s.lastAckedID).Scan(&maxID, &visible)
+		lg.metrics.RecordPoolOp(pool.Name, time.Since(start), err != nil)
+		return err
+	}, nil)
+	if err != nil {
+		lg.metrics.RecordError(classifyError(opRead, err))
+		return
+	}
+	lg.metrics.RecordRead(time.Since(intended), 16)
+
+	stale := s.lastAckedID > 0 && !visible
+	var staleness time.Duration
+	if stale {
+		staleness = start.Sub(s.lastAckedAt)
+	}
+	violation := maxID < s.maxSeenID
+	if maxID > s.maxSeenID {
+		s.maxSeenID = maxID
+	}
+	lg.metrics.RecordConsistencyCheck(pool.Name, stale, staleness, violation)
+}
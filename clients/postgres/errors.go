@@ -0,0 +1,135 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"os"
+
+	"github.com/lib/pq"
+	"github.com/souravbiswassanto/high-write-load-client/metrics"
+)
+
+// Operation names used when classifying errors
+const (
+	opRead   = "read"
+	opInsert = "insert"
+	opUpdate = "update"
+)
+
+// maxErrorNameLen caps the description of errors without a SQLSTATE, so
+// unexpected messages do not flood the report with distinct kinds
+const maxErrorNameLen = 60
+
+// phaseError tags an error with the phase of the operation it happened in
+type phaseError struct {
+	phase string
+	err   error
+}
+
+func (e *phaseError) Error() string { return e.err.Error() }
+func (e *phaseError) Unwrap() error { return e.err }
+
+// inPhase tags err with phase; nil stays nil
+func inPhase(phase string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &phaseError{phase: phase, err: err}
+}
+
+// classifyError describes a failed operation by its phase and SQLSTATE.
+// Untagged errors happened while executing. Failures to open a connection
+// are reported in the connect phase wherever they surfaced, since
+// database/sql connects lazily inside the statement call.
+func classifyError(op string, err error) metrics.ErrorKind {
+	kind := metrics.ErrorKind{Op: op, Phase: metrics.PhaseExecute}
+	var pe *phaseError
+	if errors.As(err, &pe) {
+		kind.Phase = pe.phase
+	}
+	if connectFailure(err) {
+		kind.Phase = metrics.PhaseConnect
+	}
+
+	var pqErr *pq.Error
+	var sysErr *os.SyscallError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &pqErr):
+		kind.Code = string(pqErr.Code)
+		kind.Name = pqErr.Code.Name()
+	case errors.Is(err, context.DeadlineExceeded):
+		kind.Code, kind.Name = "timeout", "context deadline exceeded"
+	case errors.Is(err, context.Canceled):
+		kind.Code, kind.Name = "canceled", "context canceled"
+	case errors.As(err, &sysErr):
+		kind.Code, kind.Name = "network", sysErr.Err.Error()
+	case errors.Is(err, driver.ErrBadConn):
+		kind.Code, kind.Name = "network", "bad connection"
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), errors.As(err, &netErr):
+		kind.Code, kind.Name = "network", "connection lost"
+	default:
+		kind.Code, kind.Name = "client", err.Error()
+		if len(kind.Name) > maxErrorNameLen {
+			kind.Name = kind.Name[:maxErrorNameLen]
+		}
+	}
+	return kind
+}
+
+// connectFailure reports whether err means a server connection could not be
+// opened: the dial failed, or the server refused the session
+func connectFailure(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		case "08", "28": // Connection exception, invalid authorization
+			return true
+		}
+		switch pqErr.Code {
+		case "53300", "57P03": // too_many_connections, cannot_connect_now
+			return true
+		}
+	}
+	return false
+}
+
+// commitOutcomeUnknown reports whether a failed single-statement write may
+// still have committed. An error reported by the server means the statement
+// was rolled back, and lib/pq only returns driver.ErrBadConn before anything
+// was sent, as nothing is when the connection could not be opened. Anything
+// else, such as the connection dropping or the context being cancelled while
+// waiting for the result, leaves the outcome unknown.
+func commitOutcomeUnknown(err error) bool {
+	if err == nil || connectFailure(err) {
+		return false
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return false
+	}
+	return !errors.Is(err, driver.ErrBadConn)
+}
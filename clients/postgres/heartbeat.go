@@ -0,0 +1,189 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/souravbiswassanto/high-write-load-client/metrics"
+)
+
+// HeartbeatMonitor measures replica apply lag. A writer updates a single
+// timestamped row on the primary every interval, and every replica is polled
+// for the newest heartbeat it has applied and its WAL replay position.
+type HeartbeatMonitor struct {
+	primary   func() *sql.DB // Returns the pool of the current primary, e.g. ConnectionManager.GetDB
+	tableName string
+	interval  time.Duration
+	metrics   *metrics.MetricsV2
+	replicas  map[string]*sql.DB
+
+	mu   sync.Mutex
+	last heartbeat // Newest heartbeat acknowledged by the primary
+}
+
+// heartbeat is one heartbeat row as written on the primary
+type heartbeat struct {
+	serverTime time.Time // clock_timestamp() on the primary
+	lsn        uint64    // pg_current_wal_lsn() when it was written
+	ackedAt    time.Time // When the client received the acknowledgement
+}
+
+// NewHeartbeatMonitor creates a monitor writing heartbeats through the pool
+// returned by primary, so heartbeats follow writes after a primary switch
+func NewHeartbeatMonitor(primary func() *sql.DB, tableName string, interval time.Duration, m *metrics.MetricsV2) *HeartbeatMonitor {
+	return &HeartbeatMonitor{
+		primary:   primary,
+		tableName: tableName + "_heartbeat",
+		interval:  interval,
+		metrics:   m,
+		replicas:  make(map[string]*sql.DB),
+	}
+}
+
+// AddReplica adds a replica to poll, such as a read pool of the
+// ConnectionManager or a Client from KubeDBClientBuilder.WithPod. It must be
+// called before Run.
+func (h *HeartbeatMonitor) AddReplica(name string, db *sql.DB) {
+	h.replicas[name] = db
+}
+
+// Initialize creates the heartbeat table
+func (h *HeartbeatMonitor) Initialize(ctx context.Context) error {
+	_, err := h.primary().ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id INT PRIMARY KEY,
+			ts TIMESTAMPTZ NOT NULL
+		)
+	`, h.tableName))
+	if err != nil {
+		return fmt.Errorf("failed to create heartbeat table: %w", err)
+	}
+	return nil
+}
+
+// Run writes heartbeats and polls every replica until ctx is done
+func (h *HeartbeatMonitor) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for name, db := range h.replicas {
+		wg.Add(1)
+		go func(name string, db *sql.DB) {
+			defer wg.Done()
+			h.pollReplica(ctx, name, db)
+		}(name, db)
+	}
+
+	h.writeHeartbeats(ctx)
+	wg.Wait()
+}
+
+// writeHeartbeats updates the heartbeat row on the primary every interval.
+// Failures are expected during a failover and simply skip a beat.
+func (h *HeartbeatMonitor) writeHeartbeats(ctx context.Context) {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (id, ts) VALUES (1, clock_timestamp())
+		ON CONFLICT (id) DO UPDATE SET ts = EXCLUDED.ts
+		RETURNING ts, pg_current_wal_lsn()::text
+	`, h.tableName)
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			writeCtx, cancel := context.WithTimeout(ctx, h.interval)
+			var hb heartbeat
+			var lsn string
+			err := h.primary().QueryRowContext(writeCtx, query).Scan(&hb.serverTime, &lsn)
+			cancel()
+			if err != nil {
+				continue
+			}
+			hb.lsn, _ = parseLSN(lsn)
+			hb.ackedAt = time.Now()
+
+			h.mu.Lock()
+			h.last = hb
+			h.mu.Unlock()
+		}
+	}
+}
+
+// pollReplica measures one replica's lag every interval
+func (h *HeartbeatMonitor) pollReplica(ctx context.Context, name string, db *sql.DB) {
+	query := fmt.Sprintf("SELECT ts, pg_last_wal_replay_lsn()::text FROM %s WHERE id = 1", h.tableName)
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pollCtx, cancel := context.WithTimeout(ctx, h.interval)
+			var applied time.Time
+			var replayLSN sql.NullString
+			err := db.QueryRowContext(pollCtx, query).Scan(&applied, &replayLSN)
+			cancel()
+
+			sample := metrics.ReplicaLag{Replica: name, At: time.Now(), Bytes: -1}
+			if err != nil {
+				sample.Err = err.Error()
+				h.metrics.RecordReplicaLag(sample)
+				continue
+			}
+
+			h.mu.Lock()
+			last := h.last
+			h.mu.Unlock()
+			if last.ackedAt.IsZero() {
+				continue
+			}
+
+			// Compare against the primary's clock, extrapolated from the newest
+			// heartbeat, so clock skew between the servers does not show up as lag
+			primaryNow := last.serverTime.Add(sample.At.Sub(last.ackedAt))
+			if lag := primaryNow.Sub(applied); lag > 0 {
+				sample.Lag = lag
+			}
+			if replayed, ok := parseLSN(replayLSN.String); ok && last.lsn > 0 {
+				sample.Bytes = 0
+				if last.lsn > replayed {
+					sample.Bytes = int64(last.lsn - replayed)
+				}
+			}
+			h.metrics.RecordReplicaLag(sample)
+		}
+	}
+}
+
+// Cleanup removes the heartbeat table
+func (h *HeartbeatMonitor) Cleanup(ctx context.Context) error {
+	_, err := h.primary().ExecContext(ctx, fmt.Sprintf("DROP TABLE IF EXISTS %s", h.tableName))
+	if err != nil {
+		return fmt.Errorf("failed to drop heartbeat table: %w", err)
+	}
+	return nil
+}
@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/souravbiswassanto/high-write-load-client/config"
+	"github.com/souravbiswassanto/high-write-load-client/ledger"
 	"github.com/souravbiswassanto/high-write-load-client/metrics"
 )
 
@@ -38,6 +39,7 @@ type LoadGenerator struct {
 	stopChan  chan struct{}
 	stopOnce  sync.Once
 	tableName string
+	mix       *operationMix // Picks between inserts and updates
 }
 
 // TestRecord represents a sample record for load testing
@@ -53,6 +55,8 @@ type TestRecord struct {
 	Data        string // Large text field for data volume
 	Status      string // Status field for filtering
 	Score       int    // Score field for sorting/filtering
+	Checksum    string // Client-computed checksum of the payload columns
+	ClientKey   string // Client-generated unique key identifying the row without its ID
 }
 
 // NewLoadGenerator creates a new load generator
@@ -70,6 +74,12 @@ func NewLoadGenerator(cm *ConnectionManager, cfg *config.Config, m *metrics.Metr
 func (lg *LoadGenerator) Initialize(ctx context.Context) error {
 	fmt.Println("Initializing load generator...")
 
+	var err error
+	lg.mix, err = newOperationMix(lg.writeMix())
+	if err != nil {
+		return fmt.Errorf("this generator only issues inserts and updates, and the workload has neither")
+	}
+
 	// Create table if it doesn't exist
 	createTableSQL := fmt.Sprintf(`
 		CREATE TABLE IF NOT EXISTS %s (
@@ -85,7 +95,7 @@ func (lg *LoadGenerator) Initialize(ctx context.Context) error {
 		)
 	`, lg.tableName)
 
-	_, err := lg.cm.GetDB().ExecContext(ctx, createTableSQL)
+	_, err = lg.cm.GetDB().ExecContext(ctx, createTableSQL)
 	if err != nil {
 		return fmt.Errorf("failed to create table: %w", err)
 	}
@@ -122,6 +132,18 @@ func (lg *LoadGenerator) Initialize(ctx context.Context) error {
 	return nil
 }
 
+// writeMix returns the inserts and updates of the configured workload. This
+// generator only writes, so reads and custom operations are left out.
+func (lg *LoadGenerator) writeMix() config.OperationMix {
+	var mix config.OperationMix
+	for _, op := range lg.config.Workload.Mix() {
+		if op.Name == opInsert || op.Name == opUpdate {
+			mix = append(mix, op)
+		}
+	}
+	return mix
+}
+
 // seedInitialData inserts initial records for update operations
 func (lg *LoadGenerator) seedInitialData(ctx context.Context, count int) error {
 	batchSize := 1000
@@ -146,6 +168,7 @@ func (lg *LoadGenerator) seedInitialData(ctx context.Context, count int) error {
 // Start starts the load generation with multiple workers
 func (lg *LoadGenerator) Start(ctx context.Context) {
 	fmt.Printf("Starting %d concurrent workers...\n", lg.config.Load.ConcurrentWriters)
+	fmt.Printf("  Workload: %s\n", lg.writeMix())
 
 	for i := 0; i < lg.config.Load.ConcurrentWriters; i++ {
 		lg.wg.Add(1)
@@ -170,8 +193,7 @@ func (lg *LoadGenerator) worker(ctx context.Context, workerID int) {
 			return
 		default:
 			// Decide operation type based on workload configuration
-			roll := rng.Intn(100)
-			if roll < lg.config.Workload.InsertPercent {
+			if lg.mix.pick(rng) == opInsert {
 				// Perform insert
 				lg.performInsert(ctx, rng)
 			} else {
@@ -327,135 +349,22 @@ func (lg *LoadGenerator) Stop() {
 	})
 }
 
-// CheckDataLoss verifies how many inserted records are actually in the database
-func (lg *LoadGenerator) CheckDataLoss(ctx context.Context) (int64, int64, error) {
-	// Get all IDs that were supposedly inserted
+// CheckDataLoss verifies that every acknowledged insert is present in the database
+func (lg *LoadGenerator) CheckDataLoss(ctx context.Context) (*VerifyResult, error) {
 	insertedIDs := lg.metrics.GetInsertedIDs()
-	if len(insertedIDs) == 0 {
-		return 0, 0, nil
-	}
-
-	totalInserted := int64(len(insertedIDs))
-	fmt.Printf("Checking data loss for %d inserted records...\n", totalInserted)
+	fmt.Printf("Checking data loss for %d inserted records...\n", len(insertedIDs))
 
-	// For very large datasets (>100K records), use a more efficient approach:
-	// Instead of checking each ID, use a range query with NOT IN for missing IDs
-	if len(insertedIDs) > 100000 {
-		fmt.Println("  Using optimized range-based verification for large dataset...")
-		return lg.checkDataLossOptimized(ctx, insertedIDs)
+	acked := &AckedWrites{
+		Inserts: []ledger.Entry{{Kind: ledger.KindInsert, IDs: insertedIDs}},
 	}
-
-	// For smaller datasets, use the batch IN query approach
-	// Use larger batches for better performance (10000 IDs per query)
-	batchSize := 10000
-	found := int64(0)
-	totalBatches := (len(insertedIDs) + batchSize - 1) / batchSize
-
-	for i := 0; i < len(insertedIDs); i += batchSize {
-		// Check context cancellation
-		select {
-		case <-ctx.Done():
-			return 0, 0, fmt.Errorf("data loss check cancelled: %w", ctx.Err())
-		default:
-		}
-
-		end := i + batchSize
-		if end > len(insertedIDs) {
-			end = len(insertedIDs)
-		}
-
-		batch := insertedIDs[i:end]
-		currentBatch := (i / batchSize) + 1
-
-		// Show progress every 5 batches or on last batch
-		if currentBatch%5 == 0 || currentBatch == totalBatches {
-			fmt.Printf("  Progress: Checked %d/%d batches (%.1f%%)\n",
-				currentBatch, totalBatches, float64(currentBatch)*100/float64(totalBatches))
-		}
-
-		// Build the IN clause
-		var idStrs []string
-		for _, id := range batch {
-			idStrs = append(idStrs, fmt.Sprintf("%d", id))
-		}
-
-		query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE id IN (%s)",
-			lg.tableName, strings.Join(idStrs, ","))
-
-		var count int64
-		err := lg.cm.GetDB().QueryRowContext(ctx, query).Scan(&count)
-		if err != nil {
-			return 0, 0, fmt.Errorf("failed to check data loss (batch %d/%d): %w", currentBatch, totalBatches, err)
-		}
-
-		found += count
-	}
-
-	lost := totalInserted - found
-	fmt.Printf("Data loss check complete: %d found, %d lost out of %d inserted\n",
-		found, lost, totalInserted)
-
-	return totalInserted, lost, nil
-}
-
-// checkDataLossOptimized uses a range-based approach for very large datasets
-func (lg *LoadGenerator) checkDataLossOptimized(ctx context.Context, insertedIDs []int64) (int64, int64, error) {
-	// Find min and max IDs
-	if len(insertedIDs) == 0 {
-		return 0, 0, nil
-	}
-
-	minID := insertedIDs[0]
-	maxID := insertedIDs[0]
-	for _, id := range insertedIDs {
-		if id < minID {
-			minID = id
-		}
-		if id > maxID {
-			maxID = id
-		}
-	}
-
-	fmt.Printf("  ID range: %d to %d\n", minID, maxID)
-
-	// Check context cancellation
-	select {
-	case <-ctx.Done():
-		return 0, 0, fmt.Errorf("data loss check cancelled: %w", ctx.Err())
-	default:
-	}
-
-	// Count how many records exist in this range
-	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE id >= %d AND id <= %d", lg.tableName, minID, maxID)
-	var foundInRange int64
-	err := lg.cm.GetDB().QueryRowContext(ctx, query).Scan(&foundInRange)
+	result, err := NewVerifier(lg.cm.GetDB(), lg.tableName).Verify(ctx, acked)
 	if err != nil {
-		return 0, 0, fmt.Errorf("failed to count records in range: %w", err)
-	}
-
-	fmt.Printf("  Records in range [%d-%d]: %d\n", minID, maxID, foundInRange)
-
-	totalInserted := int64(len(insertedIDs))
-
-	// If all expected IDs are found, we're done
-	if foundInRange >= totalInserted {
-		fmt.Printf("Data loss check complete: %d found, 0 lost out of %d inserted\n",
-			totalInserted, totalInserted)
-		return totalInserted, 0, nil
-	}
-
-	// Otherwise, estimate data loss
-	// This is an approximation - some IDs in range might not be ours
-	lost := totalInserted - foundInRange
-	if lost < 0 {
-		lost = 0
+		return nil, fmt.Errorf("failed to check data loss: %w", err)
 	}
 
-	fmt.Printf("Data loss check complete (estimated): %d found, ~%d lost out of %d inserted\n",
-		foundInRange, lost, totalInserted)
-	fmt.Println("  Note: Using range-based estimation for large dataset")
-
-	return totalInserted, lost, nil
+	fmt.Printf("Data loss check complete: %d found, %d lost out of %d inserted\n",
+		result.FoundIDs, result.LostRecords(), result.TotalIDs)
+	return result, nil
 }
 
 // Cleanup removes the test table
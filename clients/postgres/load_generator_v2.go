@@ -18,14 +18,18 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"math/rand"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/souravbiswassanto/high-write-load-client/config"
+	"github.com/souravbiswassanto/high-write-load-client/ledger"
 	"github.com/souravbiswassanto/high-write-load-client/metrics"
 )
 
@@ -39,6 +43,30 @@ type LoadGeneratorV2 struct {
 	stopOnce  sync.Once
 	tableName string
 	totalRows atomic.Int64 // Track approximate number of rows for efficient reads
+	acks      *ackTracker  // Acknowledged writes, mirrored to the optional on-disk ledger
+
+	// Source of row versions written by updates. Seeded from the clock so a
+	// restarted run keeps issuing versions above the ones already stored.
+	versionSeq atomic.Int64
+
+	// Distinguishes this run's ledger entries and worker-seq client keys from earlier runs
+	runID string
+
+	// Shared rate limiter following the load profile; nil when running closed-loop
+	pacer *pacer
+
+	// Which failed operations are retried, and the backoff in between
+	retry retryPolicy
+
+	// Throttles the workers when the server runs short of free connections
+	backpressure *backpressure
+
+	// Admits the active workers; all of them unless concurrency is adaptive
+	gate *workerGate
+
+	// The operations workers pick from, by name, and their weights
+	ops map[string]Operation
+	mix *operationMix
 }
 
 // NewLoadGeneratorV2 creates a new enhanced load generator with read support
@@ -49,7 +77,18 @@ func NewLoadGeneratorV2(cm *ConnectionManager, cfg *config.Config, m *metrics.Me
 		metrics:   m,
 		stopChan:  make(chan struct{}),
 		tableName: cfg.Workload.TableName,
+		runID:     strconv.FormatInt(time.Now().UnixNano(), 36),
+		retry:     newRetryPolicy(cfg.Retry),
 	}
+	lg.acks = newAckTracker(nil, lg.runID)
+
+	// An unlimited pool can still not use more connections than there are workers
+	poolSize := cfg.DB.MaxOpenConns
+	if poolSize <= 0 {
+		poolSize = cfg.Load.ConcurrentWriters
+	}
+	lg.backpressure = newBackpressure(cfg.DB.MinFreeConns, poolSize)
+	lg.versionSeq.Store(time.Now().UnixMicro())
 	return lg
 }
 
@@ -57,6 +96,21 @@ func NewLoadGeneratorV2(cm *ConnectionManager, cfg *config.Config, m *metrics.Me
 func (lg *LoadGeneratorV2) Initialize(ctx context.Context) error {
 	fmt.Println("Initializing enhanced load generator with read support...")
 
+	// Resolve the operation mix before touching the database
+	if err := lg.resolveOperations(); err != nil {
+		return err
+	}
+
+	// Open the ledger first so seeded rows are recorded as well
+	if lg.config.Ledger.Path != "" && lg.acks.ledger == nil {
+		w, err := ledger.Open(lg.config.Ledger.Path)
+		if err != nil {
+			return err
+		}
+		lg.acks.ledger = w
+		fmt.Printf("Recording acknowledged writes to ledger: %s (run %s)\n", w.Path(), lg.runID)
+	}
+
 	// Create table if it doesn't exist
 	createTableSQL := fmt.Sprintf(`
 		CREATE TABLE IF NOT EXISTS %s (
@@ -70,7 +124,10 @@ func (lg *LoadGeneratorV2) Initialize(ctx context.Context) error {
 			updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
 			data TEXT,
 			status VARCHAR(50) DEFAULT 'active',
-			score INT DEFAULT 0
+			score INT DEFAULT 0,
+			checksum TEXT,
+			version BIGINT NOT NULL DEFAULT 0,
+			client_key TEXT
 		)
 	`, lg.tableName)
 
@@ -79,13 +136,28 @@ func (lg *LoadGeneratorV2) Initialize(ctx context.Context) error {
 		return fmt.Errorf("failed to create table: %w", err)
 	}
 
+	// Tables created by older versions lack the columns added since
+	alterTableSQL := fmt.Sprintf(`
+		ALTER TABLE %s
+			ADD COLUMN IF NOT EXISTS checksum TEXT,
+			ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 0,
+			ADD COLUMN IF NOT EXISTS client_key TEXT
+	`, lg.tableName)
+
+	_, err = lg.cm.GetDB().ExecContext(ctx, alterTableSQL)
+	if err != nil {
+		return fmt.Errorf("failed to add columns: %w", err)
+	}
+
 	// Create indices for better read and update performance
 	createIndexSQL := fmt.Sprintf(`
 		CREATE INDEX IF NOT EXISTS idx_%s_email ON %s(email);
 		CREATE INDEX IF NOT EXISTS idx_%s_created_at ON %s(created_at);
 		CREATE INDEX IF NOT EXISTS idx_%s_status_score ON %s(status, score);
 		CREATE INDEX IF NOT EXISTS idx_%s_name ON %s(name);
+		CREATE INDEX IF NOT EXISTS idx_%s_client_key ON %s(client_key);
 	`, lg.tableName, lg.tableName,
+		lg.tableName, lg.tableName,
 		lg.tableName, lg.tableName,
 		lg.tableName, lg.tableName,
 		lg.tableName, lg.tableName)
@@ -119,8 +191,30 @@ func (lg *LoadGeneratorV2) Initialize(ctx context.Context) error {
 	return nil
 }
 
+// resolveOperations looks up the operations of the configured mix in the registry
+func (lg *LoadGeneratorV2) resolveOperations() error {
+	mix := lg.config.Workload.Mix()
+	ops := make(map[string]Operation, len(mix))
+	for _, weight := range mix {
+		op, ok := lookupOperation(weight.Name)
+		if !ok {
+			return fmt.Errorf("unknown operation %q in OPERATION_WEIGHTS, registered operations: %s",
+				weight.Name, strings.Join(RegisteredOperations(), ", "))
+		}
+		ops[weight.Name] = op
+	}
+	picker, err := newOperationMix(mix)
+	if err != nil {
+		return err
+	}
+	lg.ops = ops
+	lg.mix = picker
+	return nil
+}
+
 // seedInitialData inserts initial records
 func (lg *LoadGeneratorV2) seedInitialData(ctx context.Context, count int) error {
+	keys := newKeyGenerator(lg.config.Workload.ClientKeys, lg.runID, "seed")
 	batchSize := 1000
 	for i := 0; i < count; i += batchSize {
 		remaining := count - i
@@ -131,37 +225,78 @@ func (lg *LoadGeneratorV2) seedInitialData(ctx context.Context, count int) error
 		records := make([]TestRecord, remaining)
 		for j := 0; j < remaining; j++ {
 			records[j] = lg.generateRecord()
+			records[j].ClientKey = keys.Next()
 		}
 
-		if err := lg.batchInsert(ctx, records); err != nil {
+		entry, err := lg.batchInsert(ctx, records)
+		if err != nil {
+			return err
+		}
+		if err := lg.acks.Record(entry); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// maxPrintedStages limits how much of a long load profile is printed at start
+const maxPrintedStages = 10
+
 // Start starts the load generation with multiple workers
 func (lg *LoadGeneratorV2) Start(ctx context.Context) {
 	fmt.Printf("Starting %d concurrent workers with mixed read/write workload...\n", lg.config.Load.ConcurrentWriters)
-	fmt.Printf("  Workload: %d%% Reads, %d%% Inserts, %d%% Updates\n",
-		lg.config.Workload.ReadPercent,
-		lg.config.Workload.InsertPercent,
-		lg.config.Workload.UpdatePercent)
+	fmt.Printf("  Workload: %s\n", lg.config.Workload.Mix())
+
+	if stages := lg.config.Load.Stages; len(stages) > 0 {
+		fmt.Println("  Load profile (latency measured from each operation's intended start):")
+		for i, stage := range stages {
+			if i == maxPrintedStages {
+				fmt.Printf("    ... %d more stages\n", len(stages)-i)
+				break
+			}
+			fmt.Printf("    Stage %d: %s\n", i+1, stage)
+		}
+		lg.pacer = newPacer(stages, time.Now())
+	}
+
+	workers := lg.config.Load.ConcurrentWriters
+	if adaptive := lg.config.Adaptive; adaptive.Enabled {
+		fmt.Printf("  Adaptive concurrency: %d to %d workers, starting at %d, p99 <= %v, errors <= %.2f%%\n",
+			adaptive.MinWorkers, workers, adaptive.InitialWorkers, adaptive.TargetP99, adaptive.MaxErrorPercent)
+		workers = adaptive.InitialWorkers
+	}
+	lg.gate = newWorkerGate(workers)
+	lg.metrics.UpdateActiveWorkers(workers)
 
 	for i := 0; i < lg.config.Load.ConcurrentWriters; i++ {
 		lg.wg.Add(1)
 		go lg.worker(ctx, i)
 	}
+	if lg.config.Adaptive.Enabled {
+		lg.wg.Add(1)
+		go lg.adaptConcurrency(ctx)
+	}
 
 	fmt.Println("All workers started successfully")
 }
 
-// worker is the main worker goroutine that performs mixed operations
+// worker is the main worker goroutine that performs mixed operations. With a
+// load profile it takes intended start times from the shared pacer; otherwise
+// it runs closed-loop and each operation is intended to start when it
+// actually starts.
 func (lg *LoadGeneratorV2) worker(ctx context.Context, workerID int) {
 	defer lg.wg.Done()
 
 	// Random number generator for this worker
 	rng := rand.New(rand.NewSource(time.Now().UnixNano() + int64(workerID)))
+	w := &Worker{
+		ID:  workerID,
+		Rng: rng,
+		lg:  lg,
+		session: &workerSession{
+			keys: newKeyGenerator(lg.config.Workload.ClientKeys, lg.runID, strconv.Itoa(workerID)),
+		},
+	}
 
 	for {
 		select {
@@ -170,59 +305,96 @@ func (lg *LoadGeneratorV2) worker(ctx context.Context, workerID int) {
 		case <-lg.stopChan:
 			return
 		default:
-			// Decide operation type based on workload configuration
-			roll := rng.Intn(100)
-
-			if roll < lg.config.Workload.ReadPercent {
-				// Perform read
-				lg.performRead(ctx, rng)
-			} else if roll < lg.config.Workload.ReadPercent+lg.config.Workload.InsertPercent {
-				// Perform insert
-				lg.performInsert(ctx, rng)
-			} else {
-				// Perform update
-				lg.performUpdate(ctx, rng)
+		}
+
+		// Workers beyond the adaptive worker count wait until it grows
+		if !lg.gate.wait(ctx, lg.stopChan, workerID) {
+			return
+		}
+
+		intended := time.Now()
+		if lg.pacer != nil {
+			var ok bool
+			if intended, ok = lg.pacer.wait(ctx, lg.stopChan); !ok {
+				return
 			}
+			lg.metrics.RecordStartDelay(time.Since(intended))
 		}
+
+		// Hold off while backpressure has paused the workers; in fixed-rate
+		// mode the pause counts towards the operation's latency
+		if !lg.backpressure.wait(ctx, lg.stopChan) {
+			return
+		}
+
+		// Pick the operation by the weights of the workload configuration
+		w.Intended = intended
+		lg.ops[lg.mix.pick(rng)].Perform(ctx, w)
 	}
 }
 
-// performRead executes a read/SELECT operation
-func (lg *LoadGeneratorV2) performRead(ctx context.Context, rng *rand.Rand) {
-	start := time.Now()
+// CurrentStage returns where the load profile is now; false when running closed-loop
+func (lg *LoadGeneratorV2) CurrentStage() (StageStatus, bool) {
+	if lg.pacer == nil {
+		return StageStatus{}, false
+	}
+	return lg.pacer.Status(time.Now()), true
+}
 
+// readPool picks the pool a read is sent to: a random read pool, or the
+// write pool when no read hosts are configured
+func (lg *LoadGeneratorV2) readPool(rng *rand.Rand) Pool {
+	pools := lg.cm.ReadPools()
+	if len(pools) == 0 {
+		return Pool{Name: WritePoolName, DB: lg.cm.GetDB()}
+	}
+	return pools[rng.Intn(len(pools))]
+}
+
+// performRead executes a read/SELECT operation. Like every operation, its
+// recorded latency runs from its intended start, so time spent behind the
+// fixed-rate schedule and on retries counts; the pool records the service
+// time of each attempt alone.
+func (lg *LoadGeneratorV2) performRead(ctx context.Context, rng *rand.Rand, intended time.Time) {
 	// Various read patterns to simulate real-world scenarios
 	readPattern := rng.Intn(4)
-	var err error
 	var bytesRead int64
 
-	switch readPattern {
-	case 0:
-		// Read by ID range
-		bytesRead, err = lg.readByIDRange(ctx, rng)
-	case 1:
-		// Read by status
-		bytesRead, err = lg.readByStatus(ctx, rng)
-	case 2:
-		// Read recent records
-		bytesRead, err = lg.readRecentRecords(ctx)
-	case 3:
-		// Read by name pattern
-		bytesRead, err = lg.readByNamePattern(ctx, rng)
-	}
+	err := lg.withRetries(ctx, rng, opRead, func() error {
+		// Every attempt picks a pool, so a retry can avoid a failed read host
+		pool := lg.readPool(rng)
+		start := time.Now()
+
+		var err error
+		switch readPattern {
+		case 0:
+			// Read by ID range
+			bytesRead, err = lg.readByIDRange(ctx, pool.DB, rng)
+		case 1:
+			// Read by status
+			bytesRead, err = lg.readByStatus(ctx, pool.DB, rng)
+		case 2:
+			// Read recent records
+			bytesRead, err = lg.readRecentRecords(ctx, pool.DB)
+		case 3:
+			// Read by name pattern
+			bytesRead, err = lg.readByNamePattern(ctx, pool.DB, rng)
+		}
 
-	latency := time.Since(start)
+		lg.metrics.RecordPoolOp(pool.Name, time.Since(start), err != nil)
+		return err
+	}, nil)
 
 	if err != nil {
-		lg.metrics.RecordError()
+		lg.metrics.RecordError(classifyError(opRead, err))
 		return
 	}
 
-	lg.metrics.RecordRead(latency, bytesRead)
+	lg.metrics.RecordRead(time.Since(intended), bytesRead)
 }
 
 // readByIDRange reads records within an ID range
-func (lg *LoadGeneratorV2) readByIDRange(ctx context.Context, rng *rand.Rand) (int64, error) {
+func (lg *LoadGeneratorV2) readByIDRange(ctx context.Context, db *sql.DB, rng *rand.Rand) (int64, error) {
 	totalRows := lg.totalRows.Load()
 	if totalRows == 0 {
 		return 0, fmt.Errorf("no rows to read")
@@ -238,7 +410,7 @@ func (lg *LoadGeneratorV2) readByIDRange(ctx context.Context, rng *rand.Rand) (i
 		LIMIT $2
 	`, lg.tableName)
 
-	rows, err := lg.cm.GetDB().QueryContext(ctx, query, startID, limit)
+	rows, err := db.QueryContext(ctx, query, startID, limit)
 	if err != nil {
 		return 0, err
 	}
@@ -249,16 +421,16 @@ func (lg *LoadGeneratorV2) readByIDRange(ctx context.Context, rng *rand.Rand) (i
 		var r TestRecord
 		err := rows.Scan(&r.ID, &r.Name, &r.Email, &r.Age, &r.Address, &r.PhoneNumber, &r.Status, &r.Score, &r.Data)
 		if err != nil {
-			return bytesRead, err
+			return bytesRead, inPhase(metrics.PhaseScan, err)
 		}
 		bytesRead += int64(len(r.Data) + len(r.Name) + len(r.Email) + len(r.Address))
 	}
 
-	return bytesRead, rows.Err()
+	return bytesRead, inPhase(metrics.PhaseScan, rows.Err())
 }
 
 // readByStatus reads records with a specific status
-func (lg *LoadGeneratorV2) readByStatus(ctx context.Context, rng *rand.Rand) (int64, error) {
+func (lg *LoadGeneratorV2) readByStatus(ctx context.Context, db *sql.DB, rng *rand.Rand) (int64, error) {
 	statuses := []string{"active", "inactive", "pending"}
 	status := statuses[rng.Intn(len(statuses))]
 
@@ -270,7 +442,7 @@ func (lg *LoadGeneratorV2) readByStatus(ctx context.Context, rng *rand.Rand) (in
 		LIMIT $2
 	`, lg.tableName)
 
-	rows, err := lg.cm.GetDB().QueryContext(ctx, query, status, lg.config.Workload.ReadBatchSize)
+	rows, err := db.QueryContext(ctx, query, status, lg.config.Workload.ReadBatchSize)
 	if err != nil {
 		return 0, err
 	}
@@ -283,16 +455,16 @@ func (lg *LoadGeneratorV2) readByStatus(ctx context.Context, rng *rand.Rand) (in
 		var score int
 		err := rows.Scan(&id, &name, &email, &score, &data)
 		if err != nil {
-			return bytesRead, err
+			return bytesRead, inPhase(metrics.PhaseScan, err)
 		}
 		bytesRead += int64(len(name) + len(email) + len(data))
 	}
 
-	return bytesRead, rows.Err()
+	return bytesRead, inPhase(metrics.PhaseScan, rows.Err())
 }
 
 // readRecentRecords reads the most recently created records
-func (lg *LoadGeneratorV2) readRecentRecords(ctx context.Context) (int64, error) {
+func (lg *LoadGeneratorV2) readRecentRecords(ctx context.Context, db *sql.DB) (int64, error) {
 	query := fmt.Sprintf(`
 		SELECT id, name, email, created_at, data
 		FROM %s
@@ -300,7 +472,7 @@ func (lg *LoadGeneratorV2) readRecentRecords(ctx context.Context) (int64, error)
 		LIMIT $1
 	`, lg.tableName)
 
-	rows, err := lg.cm.GetDB().QueryContext(ctx, query, lg.config.Workload.ReadBatchSize)
+	rows, err := db.QueryContext(ctx, query, lg.config.Workload.ReadBatchSize)
 	if err != nil {
 		return 0, err
 	}
@@ -313,16 +485,16 @@ func (lg *LoadGeneratorV2) readRecentRecords(ctx context.Context) (int64, error)
 		var createdAt time.Time
 		err := rows.Scan(&id, &name, &email, &createdAt, &data)
 		if err != nil {
-			return bytesRead, err
+			return bytesRead, inPhase(metrics.PhaseScan, err)
 		}
 		bytesRead += int64(len(name) + len(email) + len(data))
 	}
 
-	return bytesRead, rows.Err()
+	return bytesRead, inPhase(metrics.PhaseScan, rows.Err())
 }
 
 // readByNamePattern reads records matching a name pattern
-func (lg *LoadGeneratorV2) readByNamePattern(ctx context.Context, rng *rand.Rand) (int64, error) {
+func (lg *LoadGeneratorV2) readByNamePattern(ctx context.Context, db *sql.DB, rng *rand.Rand) (int64, error) {
 	firstNames := []string{"John", "Jane", "Michael", "Emily", "David", "Sarah", "Robert", "Lisa", "William", "Jennifer"}
 	pattern := firstNames[rng.Intn(len(firstNames))] + "%"
 
@@ -333,7 +505,7 @@ func (lg *LoadGeneratorV2) readByNamePattern(ctx context.Context, rng *rand.Rand
 		LIMIT $2
 	`, lg.tableName)
 
-	rows, err := lg.cm.GetDB().QueryContext(ctx, query, pattern, lg.config.Workload.ReadBatchSize)
+	rows, err := db.QueryContext(ctx, query, pattern, lg.config.Workload.ReadBatchSize)
 	if err != nil {
 		return 0, err
 	}
@@ -345,18 +517,18 @@ func (lg *LoadGeneratorV2) readByNamePattern(ctx context.Context, rng *rand.Rand
 		var name, email, data string
 		err := rows.Scan(&id, &name, &email, &data)
 		if err != nil {
-			return bytesRead, err
+			return bytesRead, inPhase(metrics.PhaseScan, err)
 		}
 		bytesRead += int64(len(name) + len(email) + len(data))
 	}
 
-	return bytesRead, rows.Err()
+	return bytesRead, inPhase(metrics.PhaseScan, rows.Err())
 }
 
-// performInsert executes a batch insert operation
-func (lg *LoadGeneratorV2) performInsert(ctx context.Context, rng *rand.Rand) {
-	start := time.Now()
-
+// performInsert executes a batch insert operation. A retry inserts the same
+// records again, but under fresh client keys, so that rows of an earlier
+// in-doubt attempt are never mistaken for rows of the retry.
+func (lg *LoadGeneratorV2) performInsert(ctx context.Context, rng *rand.Rand, session *workerSession, intended time.Time) {
 	// Generate batch of records
 	records := make([]TestRecord, lg.config.Load.BatchSize)
 	for i := 0; i < lg.config.Load.BatchSize; i++ {
@@ -367,32 +539,66 @@ func (lg *LoadGeneratorV2) performInsert(ctx context.Context, rng *rand.Rand) {
 	bytesWritten := int64(len(records) * 600) // Rough estimate with new fields
 
 	// Execute batch insert
-	err := lg.batchInsert(ctx, records)
-	latency := time.Since(start)
-
+	var entry ledger.Entry
+	var start time.Time
+	err := lg.withRetries(ctx, rng, opInsert, func() error {
+		for i := range records {
+			records[i].ClientKey = session.keys.Next()
+		}
+		start = time.Now()
+		var err error
+		entry, err = lg.batchInsert(ctx, records)
+		lg.metrics.RecordPoolOp(WritePoolName, time.Since(start), err != nil)
+		return err
+	}, lg.recordWriteFailure)
 	if err != nil {
-		lg.metrics.RecordError()
+		lg.metrics.RecordError(classifyError(opInsert, err))
 		return
 	}
 
 	// Update row count
 	lg.totalRows.Add(int64(len(records)))
-	lg.metrics.RecordInsert(latency, bytesWritten)
+	session.publishAck(entry.IDs, entry.AckedAt)
+	lg.metrics.RecordWriteSuccess(start, entry.AckedAt, entry.Server)
+
+	// The batch is committed; record it outside the retries, so a ledger
+	// failure neither repeats the insert nor looks like a database outage
+	if err := lg.acks.Record(entry); err != nil {
+		lg.metrics.RecordError(classifyError(opInsert, inPhase(metrics.PhaseLedger, err)))
+		return
+	}
+	lg.metrics.RecordInsert(time.Since(intended), bytesWritten)
 }
 
-// batchInsert performs a batch insert using a single SQL statement and records inserted IDs
-func (lg *LoadGeneratorV2) batchInsert(ctx context.Context, records []TestRecord) error {
+// ackOriginSQL selects the server address, timeline, WAL position and server
+// clock for the current statement, in the order originScanArgs expects. The timeline is the first 8 hex digits of the current
+// WAL segment name, which avoids the superuser-only pg_control functions.
+const ackOriginSQL = `
+	COALESCE(host(inet_server_addr()), 'local') || ':' || COALESCE(inet_server_port(), 0)::text,
+	('x' || substr(pg_walfile_name(pg_current_wal_lsn()), 1, 8))::bit(32)::bigint,
+	pg_current_wal_lsn()::text,
+	clock_timestamp()`
+
+// originScanArgs returns the scan destinations for the columns of ackOriginSQL
+func originScanArgs(o *ledger.Origin) []interface{} {
+	return []interface{}{&o.Server, &o.Timeline, &o.LSN, &o.ServerTime}
+}
+
+// batchInsert performs a batch insert using a single SQL statement and returns
+// the acknowledged batch for the caller to record in the ledger. In-doubt
+// batches are recorded here, as only the failed attempt knows their keys.
+func (lg *LoadGeneratorV2) batchInsert(ctx context.Context, records []TestRecord) (ledger.Entry, error) {
 	if len(records) == 0 {
-		return nil
+		return ledger.Entry{}, nil
 	}
 
 	// Build bulk insert query
 	valueStrings := make([]string, 0, len(records))
-	valueArgs := make([]interface{}, 0, len(records)*9)
+	valueArgs := make([]interface{}, 0, len(records)*11)
 
 	for i, record := range records {
-		valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
-			i*9+1, i*9+2, i*9+3, i*9+4, i*9+5, i*9+6, i*9+7, i*9+8, i*9+9))
+		valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			i*11+1, i*11+2, i*11+3, i*11+4, i*11+5, i*11+6, i*11+7, i*11+8, i*11+9, i*11+10, i*11+11))
 
 		valueArgs = append(valueArgs,
 			record.Name,
@@ -404,38 +610,89 @@ func (lg *LoadGeneratorV2) batchInsert(ctx context.Context, records []TestRecord
 			record.Data,
 			record.Status,
 			record.Score,
+			record.Checksum,
+			clientKeyArg(record.ClientKey),
 		)
 	}
 
-	// Use RETURNING id to get inserted IDs for data loss tracking
+	// Return the inserted IDs together with the server, timeline and WAL
+	// position that acknowledged them, for data loss tracking
 	query := fmt.Sprintf(`
-		INSERT INTO %s (name, email, age, address, phone_number, created_at, data, status, score)
-		VALUES %s
-		RETURNING id
-	`, lg.tableName, strings.Join(valueStrings, ","))
+		WITH inserted AS (
+			INSERT INTO %s (name, email, age, address, phone_number, created_at, data, status, score, checksum, client_key)
+			VALUES %s
+			RETURNING id
+		)
+		SELECT inserted.id, %s
+		FROM inserted
+	`, lg.tableName, strings.Join(valueStrings, ","), ackOriginSQL)
+
+	entry := ledger.Entry{
+		Kind: ledger.KindInsert,
+		IDs:  make([]int64, 0, len(records)),
+	}
 
 	rows, err := lg.cm.GetDB().QueryContext(ctx, query, valueArgs...)
 	if err != nil {
-		return err
+		return entry, lg.recordInDoubt(records, err)
 	}
 	defer rows.Close()
 
-	// Record all inserted IDs for data loss tracking
 	for rows.Next() {
 		var id int64
-		if err := rows.Scan(&id); err != nil {
-			return err
+		if err := rows.Scan(append([]interface{}{&id}, originScanArgs(&entry.Origin)...)...); err != nil {
+			return entry, inPhase(metrics.PhaseScan, err)
 		}
-		lg.metrics.RecordInsertedID(id)
+		entry.IDs = append(entry.IDs, id)
 	}
+	if err := rows.Err(); err != nil {
+		return entry, lg.recordInDoubt(records, err)
+	}
+
+	// The batch is committed at this point
+	entry.AckedAt = time.Now()
+	if len(entry.IDs) > 0 {
+		lg.metrics.RecordAck(entry.Server, entry.ServerTime)
+	}
+	return entry, nil
+}
+
+// recordInDoubt records the keys of a batch whose insert failed without a
+// definite outcome, so verification can later tell whether it committed.
+// It returns the insert error, tagged with the commit phase and joined with
+// any failure to record the batch.
+func (lg *LoadGeneratorV2) recordInDoubt(records []TestRecord, err error) error {
+	if !commitOutcomeUnknown(err) {
+		return err
+	}
+	lg.metrics.RecordInDoubt()
 
-	return rows.Err()
+	// Without client keys the batch is still recorded, but cannot be resolved
+	var keys []string
+	for _, record := range records {
+		if record.ClientKey != "" {
+			keys = append(keys, record.ClientKey)
+		}
+	}
+	return inPhase(metrics.PhaseCommit, errors.Join(err, lg.acks.Record(ledger.Entry{
+		Kind:    ledger.KindInDoubt,
+		AckedAt: time.Now(),
+		Keys:    keys,
+	})))
 }
 
-// performUpdate executes an update operation
-func (lg *LoadGeneratorV2) performUpdate(ctx context.Context, rng *rand.Rand) {
-	start := time.Now()
+// recordWriteFailure records a failed write attempt and lets the connection
+// manager check whether the primary moved
+func (lg *LoadGeneratorV2) recordWriteFailure(err error) {
+	lg.metrics.RecordWriteFailure(time.Now())
+	lg.cm.ReportError(err)
+}
 
+// performUpdate executes an update operation. A retry writes the same
+// version and content, so applying it twice changes nothing, and it still
+// returns the row when an earlier attempt had already committed, so the
+// update is tracked either way.
+func (lg *LoadGeneratorV2) performUpdate(ctx context.Context, rng *rand.Rand, intended time.Time) {
 	totalRows := lg.totalRows.Load()
 	if totalRows == 0 {
 		return
@@ -444,44 +701,95 @@ func (lg *LoadGeneratorV2) performUpdate(ctx context.Context, rng *rand.Rand) {
 	// Get a random record ID to update
 	randomID := rng.Int63n(totalRows) + 1
 
-	// Update the record
+	// Update the record. Every checksummed column is rewritten so the
+	// client-computed checksum keeps covering the whole payload. Versions only
+	// ever move forward, so a slower worker holding an older version cannot
+	// roll back a row another worker has already updated. The same version
+	// matches, so a retry finds the row its own committed attempt wrote.
 	updateQuery := fmt.Sprintf(`
 		UPDATE %s
 		SET name = $1,
-		    age = $2,
-		    address = $3,
-		    updated_at = $4,
-		    score = $5,
-		    data = $6
-		WHERE id = $7
-	`, lg.tableName)
+		    email = $2,
+		    age = $3,
+		    address = $4,
+		    phone_number = $5,
+		    updated_at = $6,
+		    status = $7,
+		    score = $8,
+		    data = $9,
+		    checksum = $10,
+		    version = $11
+		WHERE id = $12 AND version <= $11
+		RETURNING %s
+	`, lg.tableName, ackOriginSQL)
 
 	record := lg.generateRecord()
-	_, err := lg.cm.GetDB().ExecContext(ctx, updateQuery,
-		record.Name,
-		record.Age,
-		record.Address,
-		time.Now(),
-		record.Score,
-		record.Data,
-		randomID,
-	)
-
-	latency := time.Since(start)
-
+	version := lg.versionSeq.Add(1)
+	var origin ledger.Origin
+	var start time.Time
+	updated := false
+	err := lg.withRetries(ctx, rng, opUpdate, func() error {
+		start = time.Now()
+		err := lg.cm.GetDB().QueryRowContext(ctx, updateQuery,
+			record.Name,
+			record.Email,
+			record.Age,
+			record.Address,
+			record.PhoneNumber,
+			time.Now(),
+			record.Status,
+			record.Score,
+			record.Data,
+			record.Checksum,
+			version,
+			randomID,
+		).Scan(originScanArgs(&origin)...)
+
+		// No row means the ID is gone or a newer version already won the row;
+		// the statement still succeeded but there is nothing to track
+		updated = err == nil
+		if errors.Is(err, sql.ErrNoRows) {
+			err = nil
+		}
+		if commitOutcomeUnknown(err) {
+			err = inPhase(metrics.PhaseCommit, err)
+		}
+		lg.metrics.RecordPoolOp(WritePoolName, time.Since(start), err != nil)
+		return err
+	}, lg.recordWriteFailure)
 	if err != nil {
-		lg.metrics.RecordError()
+		lg.metrics.RecordError(classifyError(opUpdate, err))
 		return
 	}
 
+	if updated {
+		lg.metrics.RecordWriteSuccess(start, time.Now(), origin.Server)
+		lg.metrics.RecordAck(origin.Server, origin.ServerTime)
+		if err := lg.recordUpdatedVersion(randomID, version, origin); err != nil {
+			lg.metrics.RecordError(classifyError(opUpdate, inPhase(metrics.PhaseLedger, err)))
+			return
+		}
+	}
+
 	bytesWritten := int64(500) // Rough estimate for update
-	lg.metrics.RecordUpdate(latency, bytesWritten)
+	lg.metrics.RecordUpdate(time.Since(intended), bytesWritten)
+}
+
+// recordUpdatedVersion tracks the version an acknowledged update wrote
+func (lg *LoadGeneratorV2) recordUpdatedVersion(id, version int64, origin ledger.Origin) error {
+	return lg.acks.Record(ledger.Entry{
+		Kind:     ledger.KindUpdate,
+		AckedAt:  time.Now(),
+		Origin:   origin,
+		IDs:      []int64{id},
+		Versions: []int64{version},
+	})
 }
 
 // generateRecord creates a random test record
 func (lg *LoadGeneratorV2) generateRecord() TestRecord {
 	statuses := []string{"active", "inactive", "pending"}
-	return TestRecord{
+	record := TestRecord{
 		Name:        generateRandomName(),
 		Email:       generateRandomEmail(),
 		Age:         rand.Intn(80) + 18,
@@ -492,6 +800,8 @@ func (lg *LoadGeneratorV2) generateRecord() TestRecord {
 		Status:      statuses[rand.Intn(len(statuses))],
 		Score:       rand.Intn(1000),
 	}
+	record.Checksum = record.ComputeChecksum()
+	return record
 }
 
 // Stop gracefully stops the load generator
@@ -500,138 +810,36 @@ func (lg *LoadGeneratorV2) Stop() {
 		close(lg.stopChan)
 		lg.wg.Wait()
 		fmt.Println("All workers stopped")
-	})
-}
-
-// CheckDataLoss verifies how many inserted records are actually in the database
-func (lg *LoadGeneratorV2) CheckDataLoss(ctx context.Context) (int64, int64, error) {
-	// Get all IDs that were supposedly inserted
-	insertedIDs := lg.metrics.GetInsertedIDs()
-	if len(insertedIDs) == 0 {
-		return 0, 0, nil
-	}
-
-	totalInserted := int64(len(insertedIDs))
-	fmt.Printf("Checking data loss for %d inserted records...\n", totalInserted)
-
-	// For very large datasets (>100K records), use a more efficient approach:
-	// Instead of checking each ID, use a range query with NOT IN for missing IDs
-	if len(insertedIDs) > 100000 {
-		fmt.Println("  Using optimized range-based verification for large dataset...")
-		return lg.checkDataLossOptimized(ctx, insertedIDs)
-	}
 
-	// For smaller datasets, use the batch IN query approach
-	// Use larger batches for better performance (10000 IDs per query)
-	batchSize := 10000
-	found := int64(0)
-	totalBatches := (len(insertedIDs) + batchSize - 1) / batchSize
-
-	for i := 0; i < len(insertedIDs); i += batchSize {
-		// Check context cancellation
-		select {
-		case <-ctx.Done():
-			return 0, 0, fmt.Errorf("data loss check cancelled: %w", ctx.Err())
-		default:
-		}
-
-		end := i + batchSize
-		if end > len(insertedIDs) {
-			end = len(insertedIDs)
-		}
-
-		batch := insertedIDs[i:end]
-		currentBatch := (i / batchSize) + 1
-
-		// Show progress every 5 batches or on last batch
-		if currentBatch%5 == 0 || currentBatch == totalBatches {
-			fmt.Printf("  Progress: Checked %d/%d batches (%.1f%%)\n",
-				currentBatch, totalBatches, float64(currentBatch)*100/float64(totalBatches))
+		if err := lg.acks.Close(); err != nil {
+			fmt.Printf("Warning: failed to close ledger: %v\n", err)
 		}
-
-		// Build the IN clause
-		var idStrs []string
-		for _, id := range batch {
-			idStrs = append(idStrs, fmt.Sprintf("%d", id))
-		}
-
-		query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE id IN (%s)",
-			lg.tableName, strings.Join(idStrs, ","))
-
-		var count int64
-		err := lg.cm.GetDB().QueryRowContext(ctx, query).Scan(&count)
-		if err != nil {
-			return 0, 0, fmt.Errorf("failed to check data loss (batch %d/%d): %w", currentBatch, totalBatches, err)
-		}
-
-		found += count
-	}
-
-	lost := totalInserted - found
-	fmt.Printf("Data loss check complete: %d found, %d lost out of %d inserted\n",
-		found, lost, totalInserted)
-
-	return totalInserted, lost, nil
+	})
 }
 
-// checkDataLossOptimized uses a range-based approach for very large datasets
-func (lg *LoadGeneratorV2) checkDataLossOptimized(ctx context.Context, insertedIDs []int64) (int64, int64, error) {
-	// Find min and max IDs
-	if len(insertedIDs) == 0 {
-		return 0, 0, nil
-	}
-
-	minID := insertedIDs[0]
-	maxID := insertedIDs[0]
-	for _, id := range insertedIDs {
-		if id < minID {
-			minID = id
-		}
-		if id > maxID {
-			maxID = id
-		}
-	}
-
-	fmt.Printf("  ID range: %d to %d\n", minID, maxID)
+// CheckDataLoss verifies that every acknowledged insert and update is present in the database
+func (lg *LoadGeneratorV2) CheckDataLoss(ctx context.Context) (*VerifyResult, error) {
+	acked := lg.acks.AckedWrites()
+	fmt.Printf("Checking data loss for %d inserted records, %d updated rows and %d in-doubt batches...\n",
+		acked.TotalInserted(), len(acked.UpdatedVersions), len(acked.InDoubt))
 
-	// Check context cancellation
-	select {
-	case <-ctx.Done():
-		return 0, 0, fmt.Errorf("data loss check cancelled: %w", ctx.Err())
-	default:
-	}
-
-	// Count how many records exist in this range
-	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE id >= %d AND id <= %d", lg.tableName, minID, maxID)
-	var foundInRange int64
-	err := lg.cm.GetDB().QueryRowContext(ctx, query).Scan(&foundInRange)
+	result, err := NewVerifier(lg.cm.GetDB(), lg.tableName).Verify(ctx, acked)
 	if err != nil {
-		return 0, 0, fmt.Errorf("failed to count records in range: %w", err)
+		return nil, fmt.Errorf("failed to check data loss: %w", err)
 	}
 
-	fmt.Printf("  Records in range [%d-%d]: %d\n", minID, maxID, foundInRange)
-
-	totalInserted := int64(len(insertedIDs))
-
-	// If all expected IDs are found, we're done
-	if foundInRange >= totalInserted {
-		fmt.Printf("Data loss check complete: %d found, 0 lost out of %d inserted\n",
-			totalInserted, totalInserted)
-		return totalInserted, 0, nil
-	}
+	fmt.Printf("Data loss check complete: %d found, %d lost out of %d inserted\n",
+		result.FoundIDs, result.LostRecords(), result.TotalIDs)
+	return result, nil
+}
 
-	// Otherwise, estimate data loss
-	// This is an approximation - some IDs in range might not be ours
-	lost := totalInserted - foundInRange
-	if lost < 0 {
-		lost = 0
+// FailoverReport measures RTO for every write outage of the run and, when
+// result is not nil, RPO from the acknowledged inserts it found missing
+func (lg *LoadGeneratorV2) FailoverReport(result *VerifyResult) metrics.FailoverReport {
+	if result == nil {
+		return lg.metrics.Failover().Report(nil)
 	}
-
-	fmt.Printf("Data loss check complete (estimated): %d found, ~%d lost out of %d inserted\n",
-		foundInRange, lost, totalInserted)
-	fmt.Println("  Note: Using range-based estimation for large dataset")
-
-	return totalInserted, lost, nil
+	return lg.metrics.Failover().Report(lostWrites(lg.acks.AckedWrites().Inserts, result.MissingIDs))
 }
 
 // Cleanup removes the test table
@@ -0,0 +1,144 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/souravbiswassanto/high-write-load-client/config"
+)
+
+// pacer is the global rate limiter shared by all workers. It follows a staged
+// load profile and hands out intended start times: the k-th operation is
+// intended to start when the profile's cumulative operation count reaches k.
+// Start times do not depend on how long earlier operations took, so when the
+// database stalls the backlog is issued as soon as workers are free and every
+// operation's latency is measured from when it should have started. This
+// avoids coordinated omission: a stalled database cannot make the client
+// quietly send fewer requests.
+type pacer struct {
+	start   time.Time
+	stages  []config.LoadStage
+	offsets []time.Duration // Start of each stage relative to start
+	cum     []float64       // Operations scheduled before each stage, plus the total at the end
+
+	mu   sync.Mutex
+	next int64 // Index of the next operation to hand out
+}
+
+// StageStatus describes where the load profile is at a point in time
+type StageStatus struct {
+	Index      int // Zero-based; equal to Count once the profile has ended
+	Count      int
+	Stage      config.LoadStage
+	TargetRate float64 // Operations per second the profile asks for right now
+}
+
+// newPacer creates a pacer running stages from start
+func newPacer(stages []config.LoadStage, start time.Time) *pacer {
+	p := &pacer{
+		start:   start,
+		stages:  stages,
+		offsets: make([]time.Duration, len(stages)),
+		cum:     make([]float64, len(stages)+1),
+	}
+	for i, s := range stages {
+		if i > 0 {
+			p.offsets[i] = p.offsets[i-1] + stages[i-1].Duration
+		}
+		p.cum[i+1] = p.cum[i] + float64(s.StartRate+s.EndRate)/2*s.Duration.Seconds()
+	}
+	return p
+}
+
+// intendedStart returns when operation k should start, or false when the
+// profile schedules fewer than k+1 operations
+func (p *pacer) intendedStart(k int64) (time.Time, bool) {
+	x := float64(k)
+	if x >= p.cum[len(p.stages)] {
+		return time.Time{}, false
+	}
+
+	// The stage in which the cumulative count passes x
+	i := sort.Search(len(p.stages), func(i int) bool { return p.cum[i+1] > x })
+	s := p.stages[i]
+
+	// Within a stage the rate is r0 + (r1-r0)t/D, so the count is
+	// r0*t + a*t^2 with a = (r1-r0)/2D; solve for t
+	n := x - p.cum[i]
+	r0 := float64(s.StartRate)
+	a := float64(s.EndRate-s.StartRate) / (2 * s.Duration.Seconds())
+	var t float64
+	if a == 0 {
+		t = n / r0
+	} else {
+		t = (-r0 + math.Sqrt(math.Max(r0*r0+4*a*n, 0))) / (2 * a)
+	}
+	return p.start.Add(p.offsets[i] + time.Duration(t*float64(time.Second))), true
+}
+
+// wait reserves the next operation, blocks until its intended start and
+// returns it. It returns false when ctx is done or stop is closed first, or
+// when the profile has no operations left.
+func (p *pacer) wait(ctx context.Context, stop <-chan struct{}) (time.Time, bool) {
+	p.mu.Lock()
+	intended, ok := p.intendedStart(p.next)
+	if ok {
+		p.next++
+	}
+	p.mu.Unlock()
+	if !ok {
+		return time.Time{}, false
+	}
+
+	delay := time.Until(intended)
+	if delay <= 0 {
+		return intended, true
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return intended, false
+	case <-stop:
+		return intended, false
+	case <-timer.C:
+		return intended, true
+	}
+}
+
+// Status returns the stage and target rate at the given time
+func (p *pacer) Status(at time.Time) StageStatus {
+	status := StageStatus{Count: len(p.stages)}
+	elapsed := at.Sub(p.start)
+	i := sort.Search(len(p.stages), func(i int) bool { return p.offsets[i]+p.stages[i].Duration > elapsed })
+	status.Index = i
+	if i == len(p.stages) {
+		return status
+	}
+
+	s := p.stages[i]
+	progress := math.Max((elapsed-p.offsets[i]).Seconds()/s.Duration.Seconds(), 0)
+	status.Stage = s
+	status.TargetRate = float64(s.StartRate) + float64(s.EndRate-s.StartRate)*progress
+	return status
+}
@@ -0,0 +1,146 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/souravbiswassanto/high-write-load-client/config"
+)
+
+func TestIntendedStart(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	stage := func(seconds, from, to int) config.LoadStage {
+		return config.LoadStage{Duration: time.Duration(seconds) * time.Second, StartRate: from, EndRate: to}
+	}
+
+	type op struct {
+		k    int64
+		at   time.Duration // Intended start after start
+		want bool
+	}
+	tests := []struct {
+		name   string
+		stages []config.LoadStage
+		ops    []op
+	}{
+		{
+			name:   "hold",
+			stages: []config.LoadStage{stage(10, 10, 10)},
+			ops: []op{
+				{0, 0, true},
+				{1, 100 * time.Millisecond, true},
+				{99, 9900 * time.Millisecond, true},
+				{100, 0, false},
+			},
+		},
+		{
+			// 5t^2 operations after t seconds
+			name:   "ramp up from zero",
+			stages: []config.LoadStage{stage(10, 0, 100)},
+			ops: []op{
+				{0, 0, true},
+				{5, time.Second, true},
+				{20, 2 * time.Second, true},
+				{320, 8 * time.Second, true},
+				{500, 0, false},
+			},
+		},
+		{
+			// 100t - 5t^2 operations after t seconds
+			name:   "ramp down to zero",
+			stages: []config.LoadStage{stage(10, 100, 0)},
+			ops: []op{
+				{0, 0, true},
+				{95, time.Second, true},
+				{320, 4 * time.Second, true},
+				{480, 8 * time.Second, true},
+				{500, 0, false},
+			},
+		},
+		{
+			name:   "ramp after hold",
+			stages: []config.LoadStage{stage(2, 10, 10), stage(10, 10, 30)},
+			ops: []op{
+				{19, 1900 * time.Millisecond, true},
+				// 10t + t^2 operations into the ramp
+				{20, 2 * time.Second, true},
+				{31, 3 * time.Second, true},
+				{220, 12 * time.Second, false},
+			},
+		},
+		{
+			name:   "zero-rate stages are skipped",
+			stages: []config.LoadStage{stage(5, 0, 0), stage(1, 10, 10), stage(5, 0, 0), stage(1, 10, 10), stage(5, 0, 0)},
+			ops: []op{
+				{0, 5 * time.Second, true},
+				{9, 5900 * time.Millisecond, true},
+				{10, 11 * time.Second, true},
+				{19, 11900 * time.Millisecond, true},
+				{20, 0, false},
+			},
+		},
+		{
+			name:   "only zero rates",
+			stages: []config.LoadStage{stage(10, 0, 0)},
+			ops:    []op{{0, 0, false}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPacer(tt.stages, start)
+			for _, op := range tt.ops {
+				got, ok := p.intendedStart(op.k)
+				if ok != op.want {
+					t.Errorf("intendedStart(%d) ok = %v, want %v", op.k, ok, op.want)
+					continue
+				}
+				if !ok {
+					continue
+				}
+				if diff := got.Sub(start) - op.at; diff < -time.Microsecond || diff > time.Microsecond {
+					t.Errorf("intendedStart(%d) = start+%v, want start+%v", op.k, got.Sub(start), op.at)
+				}
+			}
+		})
+	}
+}
+
+func TestIntendedStartIsMonotonic(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := newPacer([]config.LoadStage{
+		{Duration: 3 * time.Second, StartRate: 0, EndRate: 200},
+		{Duration: 2 * time.Second, StartRate: 0, EndRate: 0},
+		{Duration: 3 * time.Second, StartRate: 200, EndRate: 0},
+	}, start)
+
+	prev := start
+	for k := int64(0); ; k++ {
+		at, ok := p.intendedStart(k)
+		if !ok {
+			if k != 600 {
+				t.Errorf("profile ran out after %d operations, want 600", k)
+			}
+			return
+		}
+		if at.Before(prev) {
+			t.Fatalf("intendedStart(%d) = %v is before operation %d at %v", k, at, k-1, prev)
+		}
+		prev = at
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/souravbiswassanto/high-write-load-client/config"
+	"github.com/souravbiswassanto/high-write-load-client/metrics"
+)
+
+// retryPolicy decides which failed operations are retried and how long a
+// worker backs off first. Backing off keeps a failover from turning into a
+// tight error loop against a server that is still recovering.
+type retryPolicy struct {
+	maxAttempts  int
+	baseBackoff  time.Duration
+	maxBackoff   time.Duration
+	rules        map[string]bool // SQLSTATEs, SQLSTATE classes and client-side codes to retry
+	retryInDoubt bool
+}
+
+// newRetryPolicy creates the policy described by cfg
+func newRetryPolicy(cfg config.RetryConfig) retryPolicy {
+	p := retryPolicy{
+		maxAttempts:  cfg.MaxAttempts,
+		baseBackoff:  cfg.BaseBackoff,
+		maxBackoff:   cfg.MaxBackoff,
+		rules:        make(map[string]bool, len(cfg.RetryOn)),
+		retryInDoubt: cfg.RetryInDoubt,
+	}
+	for _, rule := range cfg.RetryOn {
+		p.rules[rule] = true
+	}
+	return p
+}
+
+// retryable reports whether a failure of the given kind is worth another
+// attempt. A write that made it into the ledger phase has already succeeded,
+// and an insert whose commit outcome is unknown may have. Updates are safe
+// to retry either way, as a retry writes the same version.
+func (p retryPolicy) retryable(kind metrics.ErrorKind) bool {
+	switch kind.Phase {
+	case metrics.PhaseLedger:
+		return false
+	case metrics.PhaseCommit:
+		if !p.retryInDoubt && kind.Op != opUpdate {
+			return false
+		}
+	}
+	if p.rules[kind.Code] {
+		return true
+	}
+	// SQLSTATEs are five characters, the first two naming their class
+	return len(kind.Code) == 5 && p.rules[kind.Code[:2]]
+}
+
+// backoff returns how long to wait before the given retry, counting from 1.
+// The wait is drawn uniformly up to a ceiling that doubles with every retry
+// ("full jitter"), so workers failing together do not retry together.
+func (p retryPolicy) backoff(retry int, rng *rand.Rand) time.Duration {
+	ceiling := p.baseBackoff
+	for i := 1; i < retry && ceiling < p.maxBackoff; i++ {
+		ceiling *= 2
+	}
+	ceiling = min(ceiling, p.maxBackoff)
+	return time.Duration(rng.Int63n(int64(ceiling) + 1))
+}
+
+// withRetries runs attempt until it succeeds, fails with an error the retry
+// policy does not cover, or runs out of attempts, backing off in between.
+// onFailure sees every failed attempt, including retried ones. The error of
+// the last attempt is returned.
+func (lg *LoadGeneratorV2) withRetries(ctx context.Context, rng *rand.Rand, op string, attempt func() error, onFailure func(error)) error {
+	for n := 1; ; n++ {
+		err := attempt()
+		if err == nil {
+			if n > 1 {
+				lg.metrics.RecordRetriedSuccess()
+			} else {
+				lg.metrics.RecordFirstAttemptSuccess()
+			}
+			return nil
+		}
+		if onFailure != nil {
+			onFailure(err)
+		}
+
+		kind := classifyError(op, err)
+		if !lg.retry.retryable(kind) {
+			return err
+		}
+		if n >= lg.retry.maxAttempts {
+			if n > 1 {
+				lg.metrics.RecordRetriesExhausted()
+			}
+			return err
+		}
+		lg.metrics.RecordRetry(kind)
+
+		timer := time.NewTimer(lg.retry.backoff(n, rng))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-lg.stopChan:
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,910 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/souravbiswassanto/high-write-load-client/ledger"
+	"github.com/souravbiswassanto/high-write-load-client/metrics"
+)
+
+// rowChecksumSQL recomputes TestRecord.ComputeChecksum server-side.
+// The two must always hash the same columns in the same order.
+const rowChecksumSQL = `md5(concat_ws('|', name, email, age::text, address, phone_number, data, status, score::text))`
+
+// Verifier checks acknowledged writes against the rows present in a database.
+// It only needs a *sql.DB, so it works against any cluster: the one under test,
+// a PITR restore, or a promoted standby.
+type Verifier struct {
+	db        *sql.DB
+	tableName string
+	batchSize int
+}
+
+// AckedWrites is everything the client was told had been committed
+type AckedWrites struct {
+	Inserts         []ledger.Entry  // Acknowledged insert batches with their origin
+	UpdatedVersions map[int64]int64 // Last acknowledged version per updated row
+	InDoubt         []ledger.Entry  // Insert batches whose commit outcome never arrived
+}
+
+// OriginLoss summarizes the missing rows acknowledged by one server on one timeline
+type OriginLoss struct {
+	ledger.Origin
+	Acknowledged int64
+	Missing      int64
+	FirstAckedAt time.Time // Of the batches that lost rows
+	LastAckedAt  time.Time
+	MinLSN       string
+	MaxLSN       string
+
+	// DiscardedByRewind counts missing rows written past the point where the
+	// surviving history forked off this timeline, i.e. WAL that pg_rewind threw away
+	DiscardedByRewind int64
+}
+
+// LostUpdate is an acknowledged update that is no longer reflected in the row
+type LostUpdate struct {
+	ID             int64
+	AckedVersion   int64
+	DurableVersion int64 // -1 when the row itself is gone
+}
+
+// DuplicateKey is a client key that more than one row carries, i.e. a write
+// that was applied several times
+type DuplicateKey struct {
+	Key  string
+	Rows int64
+}
+
+// VerifyResult holds the outcome of a verification run
+type VerifyResult struct {
+	TotalIDs     int64
+	FoundIDs     int64   // Rows present, whether intact or corrupted
+	MissingIDs   []int64 // Sorted ascending
+	CorruptedIDs []int64 // Present but the payload no longer matches its checksum
+
+	TotalUpdatedRows int64
+	LostUpdates      []LostUpdate // Sorted by ID
+
+	MissingByOrigin []OriginLoss // Sorted by server, then timeline
+	CurrentTimeline int64        // Timeline of the verified server, 0 if unknown
+
+	// In-doubt batches are resolved by their client keys and never counted as
+	// lost, since the client was never told they committed
+	InDoubtBatches   int64
+	InDoubtCommitted int64 // Rows found in the table
+	InDoubtAborted   int64 // Rows not found
+	InDoubtPartial   int64 // Batches only partly present, which an atomic insert should never leave
+	InDoubtUnkeyed   int64 // Batches written without client keys, which cannot be resolved
+
+	DuplicateKeys []DuplicateKey // Client keys stored in more than one row, sorted by key
+
+	// ContentVerified and UpdatesVerified are false when the table predates
+	// the checksum or version column, in which case those checks were skipped
+	ContentVerified bool
+	UpdatesVerified bool
+	KeysVerified    bool // False when the table has no client_key column
+}
+
+// IDRange is an inclusive run of consecutive IDs
+type IDRange struct {
+	Start int64
+	End   int64
+}
+
+// NewVerifier creates a verifier for the given table
+func NewVerifier(db *sql.DB, tableName string) *Verifier {
+	return &Verifier{
+		db:        db,
+		tableName: tableName,
+		batchSize: 10000,
+	}
+}
+
+// ComputeChecksum returns the MD5 of the record's payload columns, matching rowChecksumSQL
+func (r *TestRecord) ComputeChecksum() string {
+	payload := strings.Join([]string{
+		r.Name,
+		r.Email,
+		strconv.Itoa(r.Age),
+		r.Address,
+		r.PhoneNumber,
+		r.Data,
+		r.Status,
+		strconv.Itoa(r.Score),
+	}, "|")
+	sum := md5.Sum([]byte(payload))
+	return hex.EncodeToString(sum[:])
+}
+
+// AckedWritesFromLedger collects the acknowledged writes the given run
+// recorded in a ledger, leaving out those of other runs sharing the file
+func AckedWritesFromLedger(entries []ledger.Entry, runID string) *AckedWrites {
+	acked := &AckedWrites{
+		Inserts:         make([]ledger.Entry, 0),
+		UpdatedVersions: make(map[int64]int64),
+		InDoubt:         make([]ledger.Entry, 0),
+	}
+	for _, e := range entries {
+		if e.RunID != runID {
+			continue
+		}
+		switch e.Kind {
+		case ledger.KindInsert:
+			acked.Inserts = append(acked.Inserts, e)
+		case ledger.KindUpdate:
+			for i, id := range e.IDs {
+				if i < len(e.Versions) && e.Versions[i] > acked.UpdatedVersions[id] {
+					acked.UpdatedVersions[id] = e.Versions[i]
+				}
+			}
+		case ledger.KindInDoubt:
+			acked.InDoubt = append(acked.InDoubt, e)
+		}
+	}
+	return acked
+}
+
+// InsertedIDs returns every acknowledged insert ID
+func (a *AckedWrites) InsertedIDs() []int64 {
+	ids := make([]int64, 0, a.TotalInserted())
+	for _, e := range a.Inserts {
+		ids = append(ids, e.IDs...)
+	}
+	return ids
+}
+
+// TotalInserted returns the number of acknowledged insert IDs
+func (a *AckedWrites) TotalInserted() int {
+	total := 0
+	for _, e := range a.Inserts {
+		total += len(e.IDs)
+	}
+	return total
+}
+
+// Verify reports exactly which acknowledged inserts are missing from the table,
+// which surviving rows have a corrupted payload, and which rows have lost an
+// acknowledged update (their durable version is older than the acknowledged one).
+// The acknowledged writes are streamed into temporary tables with COPY and joined
+// against the test table, so the result is exact at any scale and unaffected by
+// rows written by anyone else. Hot standbys cannot hold temporary tables, so they
+// are checked with batched primary key lookups instead.
+func (v *Verifier) Verify(ctx context.Context, acked *AckedWrites) (*VerifyResult, error) {
+	ids := uniqueSorted(acked.InsertedIDs())
+	updated := make([]int64, 0, len(acked.UpdatedVersions))
+	for id := range acked.UpdatedVersions {
+		updated = append(updated, id)
+	}
+	sort.Slice(updated, func(i, j int) bool { return updated[i] < updated[j] })
+
+	result := &VerifyResult{
+		TotalIDs:         int64(len(ids)),
+		MissingIDs:       make([]int64, 0),
+		CorruptedIDs:     make([]int64, 0),
+		TotalUpdatedRows: int64(len(updated)),
+		LostUpdates:      make([]LostUpdate, 0),
+		InDoubtBatches:   int64(len(acked.InDoubt)),
+		DuplicateKeys:    make([]DuplicateKey, 0),
+	}
+	if len(ids) == 0 && len(updated) == 0 && len(acked.InDoubt) == 0 {
+		return result, nil
+	}
+
+	var inRecovery bool
+	if err := v.db.QueryRowContext(ctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery); err != nil {
+		return nil, fmt.Errorf("failed to check recovery state: %w", err)
+	}
+
+	// Tables written by older clients may lack the columns the checks rely on
+	var err error
+	if result.ContentVerified, err = v.hasColumn(ctx, "checksum"); err != nil {
+		return nil, err
+	}
+	if result.UpdatesVerified, err = v.hasColumn(ctx, "version"); err != nil {
+		return nil, err
+	}
+	if result.KeysVerified, err = v.hasColumn(ctx, "client_key"); err != nil {
+		return nil, err
+	}
+
+	// Rows are flagged corrupted only when they carry a checksum that no longer matches
+	corruptedSQL := "false"
+	if result.ContentVerified {
+		corruptedSQL = fmt.Sprintf("(t.checksum IS NOT NULL AND t.checksum <> %s)", rowChecksumSQL)
+	}
+
+	if inRecovery {
+		fmt.Println("  Server is a hot standby, using batched lookups...")
+		if err := v.checkInsertsByLookup(ctx, ids, corruptedSQL, result); err != nil {
+			return nil, err
+		}
+		if result.UpdatesVerified {
+			if err := v.checkUpdatesByLookup(ctx, updated, acked.UpdatedVersions, result); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		tx, err := v.db.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to begin verification transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		if err := v.checkInsertsByAntiJoin(ctx, tx, ids, corruptedSQL, result); err != nil {
+			return nil, err
+		}
+		if result.UpdatesVerified {
+			if err := v.checkUpdatesByJoin(ctx, tx, updated, acked.UpdatedVersions, result); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result.FoundIDs = result.TotalIDs - result.LostRecords()
+
+	if result.KeysVerified {
+		if err := v.resolveInDoubt(ctx, acked.InDoubt, result); err != nil {
+			return nil, err
+		}
+		if err := v.findDuplicateKeys(ctx, result); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(result.MissingIDs) > 0 {
+		var switchPoints map[int64]uint64
+		if !inRecovery {
+			switchPoints = v.timelineSwitchPoints(ctx, result)
+		}
+		result.MissingByOrigin = groupMissingByOrigin(acked.Inserts, result.MissingIDs, switchPoints)
+	}
+	return result, nil
+}
+
+// timelineSwitchPoints reads the server's timeline history and returns, for
+// every earlier timeline, the LSN at which the current history forked off it.
+// Reading the history file needs superuser or pg_read_server_files, so any
+// failure simply leaves pg_rewind attribution out of the report.
+func (v *Verifier) timelineSwitchPoints(ctx context.Context, result *VerifyResult) map[int64]uint64 {
+	err := v.db.QueryRowContext(ctx,
+		"SELECT ('x' || substr(pg_walfile_name(pg_current_wal_lsn()), 1, 8))::bit(32)::bigint").
+		Scan(&result.CurrentTimeline)
+	if err != nil || result.CurrentTimeline <= 1 {
+		return nil
+	}
+
+	var history string
+	err = v.db.QueryRowContext(ctx, "SELECT pg_read_file($1)",
+		fmt.Sprintf("pg_wal/%08X.history", result.CurrentTimeline)).Scan(&history)
+	if err != nil {
+		fmt.Printf("  Timeline history unavailable, skipping pg_rewind attribution: %v\n", err)
+		return nil
+	}
+
+	// Each line is "<parent timeline> <switch LSN> <reason>"
+	switchPoints := make(map[int64]uint64)
+	for _, line := range strings.Split(history, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		timeline, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if lsn, ok := parseLSN(fields[1]); ok {
+			switchPoints[timeline] = lsn
+		}
+	}
+	return switchPoints
+}
+
+// groupMissingByOrigin attributes missing IDs to the server and timeline that acknowledged them
+func groupMissingByOrigin(inserts []ledger.Entry, missingIDs []int64, switchPoints map[int64]uint64) []OriginLoss {
+	missing := make(map[int64]struct{}, len(missingIDs))
+	for _, id := range missingIDs {
+		missing[id] = struct{}{}
+	}
+
+	groups := make(map[ledger.Origin]*OriginLoss)
+	minLSN := make(map[ledger.Origin]uint64)
+	maxLSN := make(map[ledger.Origin]uint64)
+	for _, e := range inserts {
+		origin := ledger.Origin{Server: e.Server, Timeline: e.Timeline}
+		g, ok := groups[origin]
+		if !ok {
+			g = &OriginLoss{Origin: origin}
+			groups[origin] = g
+		}
+		g.Acknowledged += int64(len(e.IDs))
+
+		lost := int64(0)
+		for _, id := range e.IDs {
+			if _, ok := missing[id]; ok {
+				lost++
+			}
+		}
+		if lost == 0 {
+			continue
+		}
+
+		g.Missing += lost
+		if g.FirstAckedAt.IsZero() || e.AckedAt.Before(g.FirstAckedAt) {
+			g.FirstAckedAt = e.AckedAt
+		}
+		if e.AckedAt.After(g.LastAckedAt) {
+			g.LastAckedAt = e.AckedAt
+		}
+		if lsn, ok := parseLSN(e.LSN); ok {
+			if g.MinLSN == "" || lsn < minLSN[origin] {
+				g.MinLSN, minLSN[origin] = e.LSN, lsn
+			}
+			if g.MaxLSN == "" || lsn > maxLSN[origin] {
+				g.MaxLSN, maxLSN[origin] = e.LSN, lsn
+			}
+			if switchLSN, ok := switchPoints[e.Timeline]; ok && lsn >= switchLSN {
+				g.DiscardedByRewind += lost
+			}
+		}
+	}
+
+	losses := make([]OriginLoss, 0)
+	for _, g := range groups {
+		if g.Missing > 0 {
+			losses = append(losses, *g)
+		}
+	}
+	sort.Slice(losses, func(i, j int) bool {
+		if losses[i].Server != losses[j].Server {
+			return losses[i].Server < losses[j].Server
+		}
+		return losses[i].Timeline < losses[j].Timeline
+	})
+	return losses
+}
+
+// lostWrites returns, for every insert batch that lost rows, when it was
+// acknowledged and how many of its rows are missing
+func lostWrites(inserts []ledger.Entry, missingIDs []int64) []metrics.LostWrite {
+	missing := make(map[int64]struct{}, len(missingIDs))
+	for _, id := range missingIDs {
+		missing[id] = struct{}{}
+	}
+
+	lost := make([]metrics.LostWrite, 0)
+	for _, e := range inserts {
+		rows := int64(0)
+		for _, id := range e.IDs {
+			if _, ok := missing[id]; ok {
+				rows++
+			}
+		}
+		if rows > 0 {
+			lost = append(lost, metrics.LostWrite{AckedAt: e.AckedAt, Rows: rows})
+		}
+	}
+	return lost
+}
+
+// parseLSN converts a textual LSN such as "16/B374D848" to its numeric position
+func parseLSN(s string) (uint64, bool) {
+	hi, lo, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, false
+	}
+	h, err := strconv.ParseUint(hi, 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	l, err := strconv.ParseUint(lo, 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return h<<32 | l, true
+}
+
+// hasColumn reports whether the test table has the given column. The table
+// is resolved like the verification queries resolve it, through the search
+// path unless the name is schema-qualified, so a same-named table in another
+// schema cannot answer for it.
+func (v *Verifier) hasColumn(ctx context.Context, column string) (bool, error) {
+	var exists bool
+	err := v.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM pg_attribute
+			WHERE attrelid = to_regclass($1) AND attname = $2
+			  AND attnum > 0 AND NOT attisdropped
+		)
+	`, v.tableName, column).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect table columns: %w", err)
+	}
+	return exists, nil
+}
+
+// copyIntoTemp creates a temporary table and streams n rows into it with COPY
+func copyIntoTemp(ctx context.Context, tx *sql.Tx, table, definition string, columns []string, n int, row func(i int) []interface{}) error {
+	_, err := tx.ExecContext(ctx, fmt.Sprintf("CREATE TEMP TABLE %s (%s) ON COMMIT DROP", table, definition))
+	if err != nil {
+		return fmt.Errorf("failed to create temporary table %s: %w", table, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(table, columns...))
+	if err != nil {
+		return fmt.Errorf("failed to start COPY into %s: %w", table, err)
+	}
+	for i := 0; i < n; i++ {
+		if _, err := stmt.ExecContext(ctx, row(i)...); err != nil {
+			stmt.Close()
+			return fmt.Errorf("failed to COPY into %s: %w", table, err)
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		stmt.Close()
+		return fmt.Errorf("failed to finish COPY into %s: %w", table, err)
+	}
+	if err := stmt.Close(); err != nil {
+		return fmt.Errorf("failed to finish COPY into %s: %w", table, err)
+	}
+
+	// Give the planner real row counts so it picks a hash join
+	if _, err := tx.ExecContext(ctx, "ANALYZE "+table); err != nil {
+		return fmt.Errorf("failed to analyze temporary table %s: %w", table, err)
+	}
+	return nil
+}
+
+// checkInsertsByAntiJoin loads the inserted IDs into a temporary table and anti-joins them
+func (v *Verifier) checkInsertsByAntiJoin(ctx context.Context, tx *sql.Tx, ids []int64, corruptedSQL string, result *VerifyResult) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	fmt.Printf("  Loading %d acknowledged IDs with COPY...\n", len(ids))
+	err := copyIntoTemp(ctx, tx, "acked_ids", "id BIGINT NOT NULL", []string{"id"}, len(ids),
+		func(i int) []interface{} { return []interface{}{ids[i]} })
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("  Joining against the test table...")
+	query := fmt.Sprintf(`
+		SELECT a.id, t.id IS NULL
+		FROM acked_ids a
+		LEFT JOIN %s t ON t.id = a.id
+		WHERE t.id IS NULL OR %s
+		ORDER BY a.id
+	`, v.tableName, corruptedSQL)
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to find missing IDs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var missing bool
+		if err := rows.Scan(&id, &missing); err != nil {
+			return err
+		}
+		if missing {
+			result.MissingIDs = append(result.MissingIDs, id)
+		} else {
+			result.CorruptedIDs = append(result.CorruptedIDs, id)
+		}
+	}
+	return rows.Err()
+}
+
+// checkUpdatesByJoin loads the acknowledged row versions into a temporary table
+// and finds rows whose durable version is older
+func (v *Verifier) checkUpdatesByJoin(ctx context.Context, tx *sql.Tx, updated []int64, versions map[int64]int64, result *VerifyResult) error {
+	if len(updated) == 0 {
+		return nil
+	}
+
+	fmt.Printf("  Loading %d acknowledged row versions with COPY...\n", len(updated))
+	err := copyIntoTemp(ctx, tx, "acked_versions", "id BIGINT NOT NULL, version BIGINT NOT NULL", []string{"id", "version"}, len(updated),
+		func(i int) []interface{} { return []interface{}{updated[i], versions[updated[i]]} })
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT a.id, a.version, COALESCE(t.version, -1)
+		FROM acked_versions a
+		LEFT JOIN %s t ON t.id = a.id
+		WHERE t.id IS NULL OR t.version < a.version
+		ORDER BY a.id
+	`, v.tableName)
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to find lost updates: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var lu LostUpdate
+		if err := rows.Scan(&lu.ID, &lu.AckedVersion, &lu.DurableVersion); err != nil {
+			return err
+		}
+		result.LostUpdates = append(result.LostUpdates, lu)
+	}
+	return rows.Err()
+}
+
+// checkInsertsByLookup checks sorted IDs in batches of primary key lookups
+func (v *Verifier) checkInsertsByLookup(ctx context.Context, ids []int64, corruptedSQL string, result *VerifyResult) error {
+	query := fmt.Sprintf("SELECT t.id, %s FROM %s t WHERE t.id = ANY($1) ORDER BY t.id", corruptedSQL, v.tableName)
+
+	return v.lookupBatches(ctx, ids, query, func(batch []int64, rows *sql.Rows) error {
+		// Both sides are sorted, so missing IDs fall out of a single merge pass
+		next := 0
+		for rows.Next() {
+			var id int64
+			var corrupted bool
+			if err := rows.Scan(&id, &corrupted); err != nil {
+				return err
+			}
+			for next < len(batch) && batch[next] < id {
+				result.MissingIDs = append(result.MissingIDs, batch[next])
+				next++
+			}
+			if next < len(batch) && batch[next] == id {
+				next++
+			}
+			if corrupted {
+				result.CorruptedIDs = append(result.CorruptedIDs, id)
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		result.MissingIDs = append(result.MissingIDs, batch[next:]...)
+		return nil
+	})
+}
+
+// checkUpdatesByLookup compares durable row versions in batches of primary key lookups
+func (v *Verifier) checkUpdatesByLookup(ctx context.Context, updated []int64, versions map[int64]int64, result *VerifyResult) error {
+	query := fmt.Sprintf("SELECT id, version FROM %s WHERE id = ANY($1)", v.tableName)
+
+	return v.lookupBatches(ctx, updated, query, func(batch []int64, rows *sql.Rows) error {
+		durable := make(map[int64]int64, len(batch))
+		for rows.Next() {
+			var id, version int64
+			if err := rows.Scan(&id, &version); err != nil {
+				return err
+			}
+			durable[id] = version
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, id := range batch {
+			version, ok := durable[id]
+			if !ok {
+				version = -1
+			}
+			if version < versions[id] {
+				result.LostUpdates = append(result.LostUpdates, LostUpdate{
+					ID:             id,
+					AckedVersion:   versions[id],
+					DurableVersion: version,
+				})
+			}
+		}
+		return nil
+	})
+}
+
+// resolveInDoubt looks up the client keys of in-doubt batches to find out
+// whether each one committed
+func (v *Verifier) resolveInDoubt(ctx context.Context, inDoubt []ledger.Entry, result *VerifyResult) error {
+	if len(inDoubt) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0)
+	for _, e := range inDoubt {
+		if len(e.Keys) == 0 {
+			result.InDoubtUnkeyed++
+		}
+		keys = append(keys, e.Keys...)
+	}
+	fmt.Printf("  Resolving %d in-doubt batches (%d rows) by client key...\n", len(inDoubt), len(keys))
+
+	query := fmt.Sprintf("SELECT client_key FROM %s WHERE client_key = ANY($1)", v.tableName)
+	found := make(map[string]struct{}, len(keys))
+	for i := 0; i < len(keys); i += v.batchSize {
+		end := i + v.batchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		rows, err := v.db.QueryContext(ctx, query, pq.Array(keys[i:end]))
+		if err != nil {
+			return fmt.Errorf("failed to look up in-doubt keys: %w", err)
+		}
+		for rows.Next() {
+			var key string
+			if err := rows.Scan(&key); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to look up in-doubt keys: %w", err)
+			}
+			found[key] = struct{}{}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("failed to look up in-doubt keys: %w", err)
+		}
+	}
+
+	for _, e := range inDoubt {
+		present := int64(0)
+		for _, key := range e.Keys {
+			if _, ok := found[key]; ok {
+				present++
+			}
+		}
+		result.InDoubtCommitted += present
+		result.InDoubtAborted += int64(len(e.Keys)) - present
+		if present > 0 && present < int64(len(e.Keys)) {
+			result.InDoubtPartial++
+		}
+	}
+	return nil
+}
+
+// findDuplicateKeys finds client keys that were written to more than one row
+func (v *Verifier) findDuplicateKeys(ctx context.Context, result *VerifyResult) error {
+	query := fmt.Sprintf(`
+		SELECT client_key, count(*)
+		FROM %s
+		WHERE client_key IS NOT NULL
+		GROUP BY client_key
+		HAVING count(*) > 1
+		ORDER BY client_key
+	`, v.tableName)
+
+	rows, err := v.db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to find duplicate client keys: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var dup DuplicateKey
+		if err := rows.Scan(&dup.Key, &dup.Rows); err != nil {
+			return err
+		}
+		result.DuplicateKeys = append(result.DuplicateKeys, dup)
+	}
+	return rows.Err()
+}
+
+// lookupBatches runs query with each batch of sorted IDs bound to $1
+func (v *Verifier) lookupBatches(ctx context.Context, ids []int64, query string, handle func(batch []int64, rows *sql.Rows) error) error {
+	totalBatches := (len(ids) + v.batchSize - 1) / v.batchSize
+
+	for i := 0; i < len(ids); i += v.batchSize {
+		end := i + v.batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batch := ids[i:end]
+		currentBatch := (i / v.batchSize) + 1
+
+		rows, err := v.db.QueryContext(ctx, query, pq.Array(batch))
+		if err != nil {
+			return fmt.Errorf("failed to verify IDs (batch %d/%d): %w", currentBatch, totalBatches, err)
+		}
+		err = handle(batch, rows)
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("failed to verify IDs (batch %d/%d): %w", currentBatch, totalBatches, err)
+		}
+
+		if currentBatch%5 == 0 || currentBatch == totalBatches {
+			fmt.Printf("  Progress: Checked %d/%d batches (%.1f%%)\n",
+				currentBatch, totalBatches, float64(currentBatch)*100/float64(totalBatches))
+		}
+	}
+
+	return nil
+}
+
+// LostRecords returns the number of acknowledged IDs that were not found
+func (r *VerifyResult) LostRecords() int64 {
+	return int64(len(r.MissingIDs))
+}
+
+// DataLossPercent returns the share of acknowledged IDs that were not found
+func (r *VerifyResult) DataLossPercent() float64 {
+	if r.TotalIDs == 0 {
+		return 0
+	}
+	return float64(r.LostRecords()) * 100.0 / float64(r.TotalIDs)
+}
+
+// MissingRanges collapses the missing IDs into runs of consecutive IDs
+func (r *VerifyResult) MissingRanges() []IDRange {
+	return CompressIDs(r.MissingIDs)
+}
+
+// Print prints the verification result in a readable format, listing at most
+// maxRanges runs of missing IDs
+func (r *VerifyResult) Print(maxRanges int) {
+	fmt.Println("=================================================================")
+	fmt.Println("Data Loss Report:")
+	fmt.Println("-----------------------------------------------------------------")
+	fmt.Printf("  Total Records Inserted: %d\n", r.TotalIDs)
+	fmt.Printf("  Records Found in DB: %d\n", r.FoundIDs)
+	fmt.Printf("  Records Lost: %d\n", r.LostRecords())
+	fmt.Printf("  Data Loss Percentage: %.2f%%\n", r.DataLossPercent())
+	if r.ContentVerified {
+		fmt.Printf("  Records Corrupted: %d\n", len(r.CorruptedIDs))
+	} else {
+		fmt.Println("  Records Corrupted: not checked (table has no checksum column)")
+	}
+	if r.TotalUpdatedRows > 0 {
+		if r.UpdatesVerified {
+			fmt.Printf("  Lost Updates: %d out of %d updated rows\n", len(r.LostUpdates), r.TotalUpdatedRows)
+		} else {
+			fmt.Println("  Lost Updates: not checked (table has no version column)")
+		}
+	}
+	if len(r.MissingByOrigin) > 0 {
+		fmt.Println("-----------------------------------------------------------------")
+		fmt.Println("Missing Records by Acknowledging Server:")
+		for _, g := range r.MissingByOrigin {
+			server := g.Server
+			if server == "" {
+				server = "unknown server"
+			}
+			fmt.Printf("  %s, timeline %d: %d of %d acknowledged records missing\n",
+				server, g.Timeline, g.Missing, g.Acknowledged)
+			if !g.FirstAckedAt.IsZero() {
+				fmt.Printf("    Acknowledged between %s and %s\n",
+					g.FirstAckedAt.Format(time.RFC3339Nano), g.LastAckedAt.Format(time.RFC3339Nano))
+			}
+			if g.MinLSN != "" {
+				fmt.Printf("    WAL positions %s to %s\n", g.MinLSN, g.MaxLSN)
+			}
+			if g.DiscardedByRewind > 0 {
+				fmt.Printf("    %d written past the timeline %d switch point (discarded by pg_rewind)\n",
+					g.DiscardedByRewind, g.Timeline)
+			}
+		}
+		if r.CurrentTimeline > 0 {
+			fmt.Printf("  Verified server is on timeline %d\n", r.CurrentTimeline)
+		}
+	}
+	if r.InDoubtBatches > 0 {
+		fmt.Println("-----------------------------------------------------------------")
+		fmt.Printf("In-Doubt Writes (%d batches, commit outcome never received):\n", r.InDoubtBatches)
+		if r.KeysVerified {
+			fmt.Printf("  In-doubt committed: %d rows\n", r.InDoubtCommitted)
+			fmt.Printf("  In-doubt aborted: %d rows\n", r.InDoubtAborted)
+			if r.InDoubtPartial > 0 {
+				fmt.Printf("  ⚠️  %d batches are only partly present\n", r.InDoubtPartial)
+			}
+			if r.InDoubtUnkeyed > 0 {
+				fmt.Printf("  Unresolved: %d batches written without client keys\n", r.InDoubtUnkeyed)
+			}
+		} else {
+			fmt.Println("  Not resolved (table has no client_key column)")
+		}
+	}
+	if len(r.DuplicateKeys) > 0 {
+		fmt.Println("-----------------------------------------------------------------")
+		fmt.Printf("Duplicate Writes (%d client keys stored more than once):\n", len(r.DuplicateKeys))
+		for i, dup := range r.DuplicateKeys {
+			if i == maxRanges {
+				fmt.Printf("  ... and %d more keys\n", len(r.DuplicateKeys)-maxRanges)
+				break
+			}
+			fmt.Printf("  %s: %d rows\n", dup.Key, dup.Rows)
+		}
+	}
+	printIDRanges("Missing IDs", r.MissingRanges(), maxRanges)
+	printIDRanges("Corrupted IDs", CompressIDs(r.CorruptedIDs), maxRanges)
+	if len(r.LostUpdates) > 0 {
+		fmt.Println("-----------------------------------------------------------------")
+		fmt.Printf("Lost Updates (%d rows):\n", len(r.LostUpdates))
+		for i, lu := range r.LostUpdates {
+			if i == maxRanges {
+				fmt.Printf("  ... and %d more rows\n", len(r.LostUpdates)-maxRanges)
+				break
+			}
+			if lu.DurableVersion < 0 {
+				fmt.Printf("  id %d: acknowledged version %d, row missing\n", lu.ID, lu.AckedVersion)
+			} else {
+				fmt.Printf("  id %d: acknowledged version %d, durable version %d\n", lu.ID, lu.AckedVersion, lu.DurableVersion)
+			}
+		}
+	}
+	fmt.Println("=================================================================")
+}
+
+// printIDRanges prints a titled list of at most maxRanges ID ranges
+func printIDRanges(title string, ranges []IDRange, maxRanges int) {
+	if len(ranges) == 0 {
+		return
+	}
+	fmt.Println("-----------------------------------------------------------------")
+	fmt.Printf("%s (%d ranges):\n", title, len(ranges))
+	for i, rng := range ranges {
+		if i == maxRanges {
+			fmt.Printf("  ... and %d more ranges\n", len(ranges)-maxRanges)
+			break
+		}
+		fmt.Printf("  %s\n", rng)
+	}
+}
+
+// String formats the range as "start-end (n IDs)", or just the ID for a single-ID range
+func (r IDRange) String() string {
+	if r.Start == r.End {
+		return fmt.Sprintf("%d", r.Start)
+	}
+	return fmt.Sprintf("%d-%d (%d IDs)", r.Start, r.End, r.End-r.Start+1)
+}
+
+// CompressIDs collapses sorted IDs into runs of consecutive IDs
+func CompressIDs(ids []int64) []IDRange {
+	ranges := make([]IDRange, 0)
+	for _, id := range ids {
+		if n := len(ranges); n > 0 && ranges[n-1].End+1 == id {
+			ranges[n-1].End = id
+			continue
+		}
+		ranges = append(ranges, IDRange{Start: id, End: id})
+	}
+	return ranges
+}
+
+// uniqueSorted returns a sorted copy of ids with duplicates removed
+func uniqueSorted(ids []int64) []int64 {
+	sorted := make([]int64, len(ids))
+	copy(sorted, ids)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	unique := sorted[:0]
+	for i, id := range sorted {
+		if i == 0 || id != sorted[i-1] {
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,60 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompressIDs(t *testing.T) {
+	tests := []struct {
+		name string
+		ids  []int64
+		want []IDRange
+	}{
+		{"empty", nil, []IDRange{}},
+		{"single", []int64{7}, []IDRange{{7, 7}}},
+		{"one run", []int64{1, 2, 3, 4}, []IDRange{{1, 4}}},
+		{"gaps", []int64{1, 2, 4, 6, 7, 8, 10}, []IDRange{{1, 2}, {4, 4}, {6, 8}, {10, 10}}},
+		{"duplicates split runs", []int64{1, 2, 2, 3}, []IDRange{{1, 2}, {2, 3}}},
+		{"negative and zero", []int64{-2, -1, 0, 1}, []IDRange{{-2, 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CompressIDs(tt.ids); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompressIDs(%v) = %v, want %v", tt.ids, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIDRangeString(t *testing.T) {
+	tests := []struct {
+		r    IDRange
+		want string
+	}{
+		{IDRange{5, 5}, "5"},
+		{IDRange{5, 6}, "5-6 (2 IDs)"},
+		{IDRange{1, 1000}, "1-1000 (1000 IDs)"},
+	}
+	for _, tt := range tests {
+		if got := tt.r.String(); got != tt.want {
+			t.Errorf("%#v.String() = %q, want %q", tt.r, got, tt.want)
+		}
+	}
+}
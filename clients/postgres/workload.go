@@ -0,0 +1,214 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/souravbiswassanto/high-write-load-client/config"
+	"github.com/souravbiswassanto/high-write-load-client/metrics"
+)
+
+// Operation is one kind of request the workers issue. Operations are
+// registered by name, and each worker picks the next one by the weights in
+// OPERATION_WEIGHTS. Perform records the outcome in the metrics itself.
+type Operation interface {
+	Perform(ctx context.Context, w *Worker)
+}
+
+// QueryFunc runs one attempt of a custom operation against db and returns
+// the approximate number of bytes it transferred
+type QueryFunc func(ctx context.Context, db *sql.DB, w *Worker) (int64, error)
+
+// Worker is the worker running an operation, as the operation sees it
+type Worker struct {
+	ID       int
+	Rng      *rand.Rand
+	Intended time.Time // When the operation was meant to start; its latency counts from here
+
+	lg      *LoadGeneratorV2
+	session *workerSession
+}
+
+// Table returns the name of the test table
+func (w *Worker) Table() string {
+	return w.lg.tableName
+}
+
+// Rows returns the approximate number of rows in the test table; IDs run
+// from 1 up to about this number
+func (w *Worker) Rows() int64 {
+	return w.lg.totalRows.Load()
+}
+
+var (
+	operationsMu sync.RWMutex
+	operations   = make(map[string]Operation)
+)
+
+// RegisterOperation makes op available to OPERATION_WEIGHTS under name. It
+// is meant to be called from init functions and panics if name is empty or
+// already taken.
+func RegisterOperation(name string, op Operation) {
+	operationsMu.Lock()
+	defer operationsMu.Unlock()
+
+	if name == "" || strings.ContainsAny(name, ",= ") {
+		panic(fmt.Sprintf("postgres: invalid operation name %q", name))
+	}
+	if _, ok := operations[name]; ok {
+		panic(fmt.Sprintf("postgres: operation %q registered twice", name))
+	}
+	operations[name] = op
+}
+
+// RegisterQuery registers a custom operation issuing run against the write
+// pool when write is set, and against a read pool otherwise. Failed attempts
+// are classified and retried like the built-in operations, and successes are
+// counted and timed under name.
+func RegisterQuery(name string, write bool, run QueryFunc) {
+	RegisterOperation(name, queryOperation{name: name, write: write, run: run})
+}
+
+// RegisteredOperations returns the names of all registered operations, sorted
+func RegisteredOperations() []string {
+	operationsMu.RLock()
+	defer operationsMu.RUnlock()
+
+	names := make([]string, 0, len(operations))
+	for name := range operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupOperation returns the operation registered under name
+func lookupOperation(name string) (Operation, bool) {
+	operationsMu.RLock()
+	defer operationsMu.RUnlock()
+
+	op, ok := operations[name]
+	return op, ok
+}
+
+func init() {
+	RegisterOperation(opRead, readOperation{})
+	RegisterOperation(opInsert, insertOperation{})
+	RegisterOperation(opUpdate, updateOperation{})
+}
+
+// readOperation reads a batch of rows, checking replica consistency instead
+// in consistency mode
+type readOperation struct{}
+
+func (readOperation) Perform(ctx context.Context, w *Worker) {
+	if w.lg.config.Workload.ConsistencyCheck {
+		w.lg.performConsistencyRead(ctx, w.Rng, w.session, w.Intended)
+		return
+	}
+	w.lg.performRead(ctx, w.Rng, w.Intended)
+}
+
+// insertOperation inserts a batch of rows and records them as acknowledged
+type insertOperation struct{}
+
+func (insertOperation) Perform(ctx context.Context, w *Worker) {
+	w.lg.performInsert(ctx, w.Rng, w.session, w.Intended)
+}
+
+// updateOperation rewrites a random row with a new version
+type updateOperation struct{}
+
+func (updateOperation) Perform(ctx context.Context, w *Worker) {
+	w.lg.performUpdate(ctx, w.Rng, w.Intended)
+}
+
+// queryOperation is a custom operation registered with RegisterQuery
+type queryOperation struct {
+	name  string
+	write bool
+	run   QueryFunc
+}
+
+func (q queryOperation) Perform(ctx context.Context, w *Worker) {
+	lg := w.lg
+	var onFailure func(error)
+	if q.write {
+		onFailure = lg.recordWriteFailure
+	}
+
+	var bytes int64
+	err := lg.withRetries(ctx, w.Rng, q.name, func() error {
+		pool := Pool{Name: WritePoolName, DB: lg.cm.GetDB()}
+		if !q.write {
+			pool = lg.readPool(w.Rng)
+		}
+		start := time.Now()
+
+		var err error
+		bytes, err = q.run(ctx, pool.DB, w)
+		if q.write && commitOutcomeUnknown(err) {
+			err = inPhase(metrics.PhaseCommit, err)
+		}
+		lg.metrics.RecordPoolOp(pool.Name, time.Since(start), err != nil)
+		return err
+	}, onFailure)
+	if err != nil {
+		lg.metrics.RecordError(classifyError(q.name, err))
+		return
+	}
+
+	lg.metrics.RecordOperation(q.name, time.Since(w.Intended), bytes)
+}
+
+// operationMix picks operation names at random by their weights
+type operationMix struct {
+	names      []string
+	cumulative []int // Running total of the weights, in the order of names
+}
+
+// newOperationMix creates a picker for the given mix; it fails when the mix is empty
+func newOperationMix(mix config.OperationMix) (*operationMix, error) {
+	m := &operationMix{}
+	total := 0
+	for _, op := range mix {
+		if op.Weight <= 0 {
+			continue
+		}
+		total += op.Weight
+		m.names = append(m.names, op.Name)
+		m.cumulative = append(m.cumulative, total)
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("no operation has a positive weight")
+	}
+	return m, nil
+}
+
+// pick returns the name of a random operation
+func (m *operationMix) pick(rng *rand.Rand) string {
+	roll := rng.Intn(m.cumulative[len(m.cumulative)-1])
+	return m.names[sort.SearchInts(m.cumulative, roll+1)]
+}
@@ -18,8 +18,10 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -33,17 +35,46 @@ type Config struct {
 
 	// Workload distribution
 	Workload WorkloadConfig
+
+	// Acknowledged write ledger
+	Ledger LedgerConfig
+
+	// Replica lag measurement
+	Replication ReplicationConfig
+
+	// Prometheus endpoint
+	Metrics MetricsConfig
+
+	// Saturation search of the find-max subcommand
+	FindMax FindMaxConfig
+
+	// JSON run summary and pass/fail assertions
+	Summary SummaryConfig
+
+	// Retries of failed operations
+	Retry RetryConfig
+
+	// Adaptive worker count
+	Adaptive AdaptiveConfig
 }
 
 // DBConfig contains database connection information
 type DBConfig struct {
-	Host     string
+	Host     string   // First entry of Hosts, without its port
+	Hosts    []string // Candidate hosts, optionally as host:port, tried in order
 	Port     int
 	User     string
 	Password string
 	DBName   string
 	SSLMode  string
 
+	// Which of Hosts to use, as in libpq's target_session_attrs
+	TargetSessionAttrs string
+
+	// Standby hosts that reads are spread over and whose replication lag is
+	// measured; empty sends reads to Host
+	ReadHosts []string
+
 	// Connection pool settings
 	MaxOpenConns int
 	MaxIdleConns int
@@ -56,6 +87,14 @@ type LoadConfig struct {
 	Duration          time.Duration // Test duration
 	BatchSize         int           // Number of records per batch insert
 	ReportInterval    time.Duration // How often to report metrics
+
+	// Target operations per second across all workers. Zero runs closed-loop:
+	// every worker issues its next operation as soon as the previous one ends.
+	TargetRate int
+
+	// Staged load profile; the run lasts as long as all stages together.
+	// A TargetRate becomes a single stage holding it for the whole run.
+	Stages []LoadStage
 }
 
 // WorkloadConfig defines the workload distribution
@@ -64,22 +103,164 @@ type WorkloadConfig struct {
 	InsertPercent int    // Percentage of insert operations (0-100)
 	UpdatePercent int    // Percentage of update operations (0-100)
 	TableName     string // Test table name
+	CleanupTable  bool   // Drop the test table when the run finishes
+	ClientKeys    string // Format of the client-generated key stored with every inserted row
+
+	// Route reads to the read hosts and check read-your-writes and monotonic reads
+	ConsistencyCheck bool
 
 	// Read operation settings
 	ReadBatchSize int // Number of records to fetch per read operation
+
+	// Operations the workers pick from, by weight; overrides the percentages
+	// above when set
+	Operations OperationMix
+}
+
+// Mix returns the operations the workers pick from: Operations when set,
+// otherwise reads, inserts and updates weighted by their percentages
+func (w WorkloadConfig) Mix() OperationMix {
+	if len(w.Operations) > 0 {
+		return w.Operations
+	}
+	var mix OperationMix
+	for _, op := range []OperationWeight{
+		{Name: "read", Weight: w.ReadPercent},
+		{Name: "insert", Weight: w.InsertPercent},
+		{Name: "update", Weight: w.UpdatePercent},
+	} {
+		if op.Weight > 0 {
+			mix = append(mix, op)
+		}
+	}
+	return mix
+}
+
+// Target session attributes
+const (
+	TargetReadWrite = "read-write" // The primary
+	TargetAny       = "any"        // The first reachable host
+)
+
+// Client key formats
+const (
+	ClientKeysUUIDv7    = "uuidv7"     // Time-ordered random UUID
+	ClientKeysWorkerSeq = "worker-seq" // Run ID, worker and per-worker sequence number
+	ClientKeysNone      = "none"       // No client key; rows are only identified by id
+)
+
+// LedgerConfig controls the on-disk ledger of acknowledged writes
+type LedgerConfig struct {
+	Path string // Ledger file location; empty disables the ledger
+}
+
+// ReplicationConfig controls heartbeat-based lag measurement on the read hosts
+type ReplicationConfig struct {
+	HeartbeatInterval time.Duration // How often heartbeats are written and read hosts polled; 0 disables lag measurement
+}
+
+// MetricsConfig controls the Prometheus /metrics endpoint
+type MetricsConfig struct {
+	Addr   string        // Listen address, e.g. ":9090"; empty disables the endpoint
+	Linger time.Duration // How long to keep serving after the run so the final values get scraped
+}
+
+// FindMaxConfig controls the saturation search: the target rate is stepped
+// up until a step misses the SLOs
+type FindMaxConfig struct {
+	StartRate    int           // Rate of the first step in ops/sec
+	StepRate     int           // Rate added per step
+	MaxRate      int           // Rate of the last step
+	StepDuration time.Duration // How long each step holds its rate
+
+	SLOP99          time.Duration // Highest acceptable p99 latency of every operation type
+	SLOErrorPercent float64       // Highest acceptable share of failed operations
+}
+
+// Validate checks the search settings. Only the find-max subcommand uses
+// them, so it is not part of Config.Validate.
+func (f FindMaxConfig) Validate() error {
+	if f.StartRate < 1 || f.StepRate < 1 {
+		return fmt.Errorf("FIND_MAX_START_RATE and FIND_MAX_STEP_RATE must be at least 1")
+	}
+	if f.MaxRate < f.StartRate {
+		return fmt.Errorf("FIND_MAX_MAX_RATE must be at least FIND_MAX_START_RATE")
+	}
+	if f.StepDuration < time.Second {
+		return fmt.Errorf("FIND_MAX_STEP_DURATION must be at least 1 second")
+	}
+	if f.SLOP99 <= 0 || f.SLOErrorPercent < 0 {
+		return fmt.Errorf("SLO_P99_MS must be positive and SLO_ERROR_PERCENT cannot be negative")
+	}
+	return nil
+}
+
+// SummaryConfig controls the JSON run summary and the assertions that decide
+// whether the run passed
+type SummaryConfig struct {
+	Path string // Where to write the JSON summary; empty disables it
+
+	MaxDataLoss     int64         // Most lost, corrupted or rolled-back rows allowed; negative disables
+	MaxP99          time.Duration // Highest p99 of any operation type; zero disables
+	MaxErrorPercent float64       // Highest share of failed operations; negative disables
+}
+
+// AdaptiveConfig controls adaptive concurrency: the number of active
+// workers grows additively while latency and errors stay within the targets
+// and shrinks multiplicatively when they do not. CONCURRENT_WRITERS is the
+// upper bound.
+type AdaptiveConfig struct {
+	Enabled         bool
+	TargetP99       time.Duration // Highest acceptable p99 of every operation type per interval
+	MaxErrorPercent float64       // Highest acceptable share of failed operations per interval
+	MinWorkers      int
+	InitialWorkers  int
+	Increase        int           // Workers added after a good interval
+	DecreaseFactor  float64       // Share of workers kept after a bad interval
+	Interval        time.Duration // How often the worker count is adjusted
+}
+
+// RetryConfig controls which failed operations are retried and how long
+// workers back off between attempts
+type RetryConfig struct {
+	MaxAttempts int           // Attempts per operation including the first; 1 disables retries
+	BaseBackoff time.Duration // Backoff ceiling before the first retry, doubled for every further one
+	MaxBackoff  time.Duration // Highest backoff ceiling
+
+	// Errors to retry: SQLSTATEs (40001), SQLSTATE classes (08) or the
+	// client-side codes network, timeout, canceled and client
+	RetryOn []string
+
+	// Also retry writes whose commit outcome is unknown; a retried insert may then be applied twice
+	RetryInDoubt bool
 }
 
+// DefaultRetryOn are the errors retried unless RETRY_ON is set: lost and
+// refused connections, serialization failures and deadlocks, server
+// shutdowns and writes rejected by a demoted primary
+var DefaultRetryOn = []string{"network", "08", "40001", "40P01", "57P01", "57P02", "57P03", "53300", "25006"}
+
 // LoadFromEnv loads configuration from environment variables
 func LoadFromEnv() (*Config, error) {
 	cfg := &Config{}
 
 	// Database configuration
-	cfg.DB.Host = getEnv("DB_HOST", "localhost")
+	cfg.DB.Hosts = getEnvAsList("DB_HOST")
+	if len(cfg.DB.Hosts) == 0 {
+		cfg.DB.Hosts = []string{"localhost"}
+	}
+	cfg.DB.TargetSessionAttrs = getEnv("DB_TARGET_SESSION_ATTRS", TargetReadWrite)
 	cfg.DB.Port = getEnvAsInt("DB_PORT", 5432)
+	cfg.DB.Host = cfg.DB.ForHost(cfg.DB.Hosts[0]).Host
 	cfg.DB.User = getEnv("DB_USER", "postgres")
 	cfg.DB.Password = getEnv("DB_PASSWORD", "")
 	cfg.DB.DBName = getEnv("DB_NAME", "testdb")
 	cfg.DB.SSLMode = getEnv("DB_SSL_MODE", "disable")
+	cfg.DB.ReadHosts = getEnvAsList("DB_READ_HOSTS")
+	if len(cfg.DB.ReadHosts) == 0 {
+		// Former name, from when standbys were only polled for their lag
+		cfg.DB.ReadHosts = getEnvAsList("REPLICA_HOSTS")
+	}
 	cfg.DB.MaxOpenConns = getEnvAsInt("DB_MAX_OPEN_CONNS", 50)
 	cfg.DB.MaxIdleConns = getEnvAsInt("DB_MAX_IDLE_CONNS", 10)
 	cfg.DB.MinFreeConns = getEnvAsInt("DB_MIN_FREE_CONNS", 5)
@@ -91,6 +272,24 @@ func LoadFromEnv() (*Config, error) {
 	cfg.Load.BatchSize = getEnvAsInt("BATCH_SIZE", 100)
 	reportIntervalSecs := getEnvAsInt("REPORT_INTERVAL", 10) // Default 10 seconds
 	cfg.Load.ReportInterval = time.Duration(reportIntervalSecs) * time.Second
+	cfg.Load.TargetRate = getEnvAsInt("TARGET_RATE", 0)
+	stages, err := ParseLoadStages(getEnv("LOAD_STAGES", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOAD_STAGES: %w", err)
+	}
+	switch {
+	case len(stages) > 0 && cfg.Load.TargetRate > 0:
+		return nil, fmt.Errorf("set either TARGET_RATE or LOAD_STAGES, not both")
+	case len(stages) > 0:
+		cfg.Load.Stages = stages
+		cfg.Load.Duration = TotalDuration(stages)
+	case cfg.Load.TargetRate > 0:
+		cfg.Load.Stages = []LoadStage{{
+			Duration:  cfg.Load.Duration,
+			StartRate: cfg.Load.TargetRate,
+			EndRate:   cfg.Load.TargetRate,
+		}}
+	}
 
 	// Workload configuration
 	cfg.Workload.ReadPercent = getEnvAsInt("READ_PERCENT", 0)
@@ -98,6 +297,68 @@ func LoadFromEnv() (*Config, error) {
 	cfg.Workload.UpdatePercent = getEnvAsInt("UPDATE_PERCENT", 30)
 	cfg.Workload.TableName = getEnv("TABLE_NAME", "load_test_data")
 	cfg.Workload.ReadBatchSize = getEnvAsInt("READ_BATCH_SIZE", 10)
+	cfg.Workload.CleanupTable = getEnvAsBool("CLEANUP_TABLE", true)
+	cfg.Workload.ClientKeys = getEnv("CLIENT_KEYS", ClientKeysUUIDv7)
+	cfg.Workload.ConsistencyCheck = getEnvAsBool("CONSISTENCY_CHECK", false)
+	if spec := getEnv("OPERATION_WEIGHTS", ""); spec != "" {
+		cfg.Workload.Operations, err = ParseOperationMix(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OPERATION_WEIGHTS: %w", err)
+		}
+	}
+
+	// Ledger configuration
+	cfg.Ledger.Path = getEnv("LEDGER_PATH", "")
+
+	// Replication lag configuration
+	heartbeatIntervalMs := getEnvAsInt("HEARTBEAT_INTERVAL_MS", 1000)
+	cfg.Replication.HeartbeatInterval = time.Duration(heartbeatIntervalMs) * time.Millisecond
+
+	// Saturation search configuration
+	cfg.FindMax.StartRate = getEnvAsInt("FIND_MAX_START_RATE", 100)
+	cfg.FindMax.StepRate = getEnvAsInt("FIND_MAX_STEP_RATE", 100)
+	cfg.FindMax.MaxRate = getEnvAsInt("FIND_MAX_MAX_RATE", 100000)
+	stepDurationSecs := getEnvAsInt("FIND_MAX_STEP_DURATION", 60)
+	cfg.FindMax.StepDuration = time.Duration(stepDurationSecs) * time.Second
+	sloP99Ms := getEnvAsInt("SLO_P99_MS", 100)
+	cfg.FindMax.SLOP99 = time.Duration(sloP99Ms) * time.Millisecond
+	cfg.FindMax.SLOErrorPercent = getEnvAsFloat("SLO_ERROR_PERCENT", 1)
+
+	// Run summary configuration
+	cfg.Summary.Path = getEnv("SUMMARY_PATH", "")
+	cfg.Summary.MaxDataLoss = int64(getEnvAsInt("ASSERT_MAX_DATA_LOSS", 0))
+	assertMaxP99Ms := getEnvAsInt("ASSERT_MAX_P99_MS", 0)
+	cfg.Summary.MaxP99 = time.Duration(assertMaxP99Ms) * time.Millisecond
+	cfg.Summary.MaxErrorPercent = getEnvAsFloat("ASSERT_MAX_ERROR_PERCENT", -1)
+
+	// Retry configuration
+	cfg.Retry.MaxAttempts = getEnvAsInt("RETRY_MAX_ATTEMPTS", 3)
+	retryBackoffMs := getEnvAsInt("RETRY_BACKOFF_MS", 50)
+	cfg.Retry.BaseBackoff = time.Duration(retryBackoffMs) * time.Millisecond
+	retryMaxBackoffMs := getEnvAsInt("RETRY_MAX_BACKOFF_MS", 2000)
+	cfg.Retry.MaxBackoff = time.Duration(retryMaxBackoffMs) * time.Millisecond
+	cfg.Retry.RetryOn = getEnvAsList("RETRY_ON")
+	if len(cfg.Retry.RetryOn) == 0 {
+		cfg.Retry.RetryOn = DefaultRetryOn
+	}
+	cfg.Retry.RetryInDoubt = getEnvAsBool("RETRY_IN_DOUBT", false)
+
+	// Adaptive concurrency configuration
+	cfg.Adaptive.Enabled = getEnvAsBool("ADAPTIVE_CONCURRENCY", false)
+	adaptiveP99Ms := getEnvAsInt("ADAPTIVE_TARGET_P99_MS", 100)
+	cfg.Adaptive.TargetP99 = time.Duration(adaptiveP99Ms) * time.Millisecond
+	cfg.Adaptive.MaxErrorPercent = getEnvAsFloat("ADAPTIVE_MAX_ERROR_PERCENT", 1)
+	cfg.Adaptive.MinWorkers = getEnvAsInt("ADAPTIVE_MIN_WORKERS", 1)
+	cfg.Adaptive.InitialWorkers = getEnvAsInt("ADAPTIVE_INITIAL_WORKERS", min(10, cfg.Load.ConcurrentWriters))
+	cfg.Adaptive.Increase = getEnvAsInt("ADAPTIVE_INCREASE", 5)
+	cfg.Adaptive.DecreaseFactor = getEnvAsFloat("ADAPTIVE_DECREASE_FACTOR", 0.5)
+	adaptiveIntervalSecs := getEnvAsInt("ADAPTIVE_INTERVAL", 5)
+	cfg.Adaptive.Interval = time.Duration(adaptiveIntervalSecs) * time.Second
+
+	// Metrics endpoint configuration
+	cfg.Metrics.Addr = getEnv("METRICS_ADDR", "")
+	metricsLingerSecs := getEnvAsInt("METRICS_LINGER", 0)
+	cfg.Metrics.Linger = time.Duration(metricsLingerSecs) * time.Second
 
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
@@ -118,6 +379,16 @@ func (c *Config) Validate() error {
 	if c.DB.DBName == "" {
 		return fmt.Errorf("DB_NAME cannot be empty")
 	}
+	switch c.DB.TargetSessionAttrs {
+	case TargetReadWrite, TargetAny:
+	case "prefer-standby":
+		// The chosen host takes the writes, which a standby rejects
+		return fmt.Errorf("DB_TARGET_SESSION_ATTRS=prefer-standby would send writes to a read-only standby; "+
+			"use %s and route reads to standbys with DB_READ_HOSTS", TargetReadWrite)
+	default:
+		return fmt.Errorf("DB_TARGET_SESSION_ATTRS must be %s or %s, got %q",
+			TargetReadWrite, TargetAny, c.DB.TargetSessionAttrs)
+	}
 	if c.DB.MinFreeConns < 1 {
 		return fmt.Errorf("DB_MIN_FREE_CONNS must be at least 1")
 	}
@@ -130,31 +401,103 @@ func (c *Config) Validate() error {
 	if c.Load.BatchSize < 1 {
 		return fmt.Errorf("BATCH_SIZE must be at least 1")
 	}
+	if c.Load.TargetRate < 0 {
+		return fmt.Errorf("TARGET_RATE cannot be negative")
+	}
+
+	// Validate workload percentages, unless OPERATION_WEIGHTS replaces them
+	if len(c.Workload.Operations) == 0 {
+		totalPercent := c.Workload.ReadPercent + c.Workload.InsertPercent + c.Workload.UpdatePercent
+		if totalPercent != 100 {
+			return fmt.Errorf("READ_PERCENT + INSERT_PERCENT + UPDATE_PERCENT must equal 100, got %d + %d + %d = %d",
+				c.Workload.ReadPercent, c.Workload.InsertPercent, c.Workload.UpdatePercent, totalPercent)
+		}
+
+		if c.Workload.ReadPercent < 0 || c.Workload.ReadPercent > 100 {
+			return fmt.Errorf("READ_PERCENT must be between 0 and 100, got %d", c.Workload.ReadPercent)
+		}
+		if c.Workload.InsertPercent < 0 || c.Workload.InsertPercent > 100 {
+			return fmt.Errorf("INSERT_PERCENT must be between 0 and 100, got %d", c.Workload.InsertPercent)
+		}
+		if c.Workload.UpdatePercent < 0 || c.Workload.UpdatePercent > 100 {
+			return fmt.Errorf("UPDATE_PERCENT must be between 0 and 100, got %d", c.Workload.UpdatePercent)
+		}
+	}
 
-	// Validate workload percentages
-	totalPercent := c.Workload.ReadPercent + c.Workload.InsertPercent + c.Workload.UpdatePercent
-	if totalPercent != 100 {
-		return fmt.Errorf("READ_PERCENT + INSERT_PERCENT + UPDATE_PERCENT must equal 100, got %d + %d + %d = %d",
-			c.Workload.ReadPercent, c.Workload.InsertPercent, c.Workload.UpdatePercent, totalPercent)
+	if c.Workload.ReadBatchSize < 1 {
+		return fmt.Errorf("READ_BATCH_SIZE must be at least 1")
 	}
 
-	if c.Workload.ReadPercent < 0 || c.Workload.ReadPercent > 100 {
-		return fmt.Errorf("READ_PERCENT must be between 0 and 100, got %d", c.Workload.ReadPercent)
+	if c.Workload.ConsistencyCheck && len(c.DB.ReadHosts) == 0 {
+		return fmt.Errorf("CONSISTENCY_CHECK requires DB_READ_HOSTS")
 	}
-	if c.Workload.InsertPercent < 0 || c.Workload.InsertPercent > 100 {
-		return fmt.Errorf("INSERT_PERCENT must be between 0 and 100, got %d", c.Workload.InsertPercent)
+	if c.Replication.HeartbeatInterval != 0 && c.Replication.HeartbeatInterval < 10*time.Millisecond {
+		return fmt.Errorf("HEARTBEAT_INTERVAL_MS must be 0 or at least 10")
 	}
-	if c.Workload.UpdatePercent < 0 || c.Workload.UpdatePercent > 100 {
-		return fmt.Errorf("UPDATE_PERCENT must be between 0 and 100, got %d", c.Workload.UpdatePercent)
+
+	if c.Metrics.Linger < 0 {
+		return fmt.Errorf("METRICS_LINGER cannot be negative")
 	}
 
-	if c.Workload.ReadBatchSize < 1 {
-		return fmt.Errorf("READ_BATCH_SIZE must be at least 1")
+	if c.Adaptive.Enabled {
+		if len(c.Load.Stages) > 0 {
+			return fmt.Errorf("ADAPTIVE_CONCURRENCY cannot be combined with TARGET_RATE or LOAD_STAGES")
+		}
+		if c.Adaptive.MinWorkers < 1 || c.Adaptive.MinWorkers > c.Load.ConcurrentWriters {
+			return fmt.Errorf("ADAPTIVE_MIN_WORKERS must be between 1 and CONCURRENT_WRITERS")
+		}
+		if c.Adaptive.InitialWorkers < c.Adaptive.MinWorkers || c.Adaptive.InitialWorkers > c.Load.ConcurrentWriters {
+			return fmt.Errorf("ADAPTIVE_INITIAL_WORKERS must be between ADAPTIVE_MIN_WORKERS and CONCURRENT_WRITERS")
+		}
+		if c.Adaptive.Increase < 1 {
+			return fmt.Errorf("ADAPTIVE_INCREASE must be at least 1")
+		}
+		if c.Adaptive.DecreaseFactor <= 0 || c.Adaptive.DecreaseFactor >= 1 {
+			return fmt.Errorf("ADAPTIVE_DECREASE_FACTOR must be between 0 and 1")
+		}
+		if c.Adaptive.TargetP99 <= 0 || c.Adaptive.MaxErrorPercent < 0 {
+			return fmt.Errorf("ADAPTIVE_TARGET_P99_MS must be positive and ADAPTIVE_MAX_ERROR_PERCENT cannot be negative")
+		}
+		if c.Adaptive.Interval < time.Second {
+			return fmt.Errorf("ADAPTIVE_INTERVAL must be at least 1 second")
+		}
+	}
+
+	if c.Retry.MaxAttempts < 1 {
+		return fmt.Errorf("RETRY_MAX_ATTEMPTS must be at least 1")
+	}
+	if c.Retry.BaseBackoff <= 0 || c.Retry.MaxBackoff < c.Retry.BaseBackoff {
+		return fmt.Errorf("RETRY_BACKOFF_MS must be positive and RETRY_MAX_BACKOFF_MS at least RETRY_BACKOFF_MS")
+	}
+
+	if c.Summary.MaxP99 < 0 {
+		return fmt.Errorf("ASSERT_MAX_P99_MS cannot be negative")
+	}
+
+	switch c.Workload.ClientKeys {
+	case ClientKeysUUIDv7, ClientKeysWorkerSeq, ClientKeysNone:
+	default:
+		return fmt.Errorf("CLIENT_KEYS must be one of %s, %s or %s, got %q",
+			ClientKeysUUIDv7, ClientKeysWorkerSeq, ClientKeysNone, c.Workload.ClientKeys)
 	}
 
 	return nil
 }
 
+// ForHost returns a copy of the configuration connecting to host, which may
+// carry its own port as host:port
+func (c *DBConfig) ForHost(host string) DBConfig {
+	hostCfg := *c
+	hostCfg.Host = host
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		if port, err := strconv.Atoi(p); err == nil {
+			hostCfg.Host = h
+			hostCfg.Port = port
+		}
+	}
+	return hostCfg
+}
+
 // GetConnectionString returns the PostgreSQL connection string
 func (c *DBConfig) GetConnectionString() string {
 	return fmt.Sprintf(
@@ -182,3 +525,37 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return value
 }
+
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
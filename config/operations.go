@@ -0,0 +1,83 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// OperationWeight is how often workers pick a named operation, relative to
+// the other operations of the mix
+type OperationWeight struct {
+	Name   string
+	Weight int
+}
+
+// OperationMix is the weighted set of operations the workers issue
+type OperationMix []OperationWeight
+
+// String describes the mix in percent, e.g. "20% read, 50% insert, 30% update"
+func (m OperationMix) String() string {
+	total := 0
+	for _, op := range m {
+		total += op.Weight
+	}
+	parts := make([]string, 0, len(m))
+	for _, op := range m {
+		parts = append(parts, fmt.Sprintf("%.4g%% %s", float64(op.Weight)*100/float64(total), op.Name))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ParseOperationMix parses comma-separated <operation>=<weight> pairs, e.g.
+// "read=20,insert=50,update=20,delete=10". Weights are relative and need not
+// add up to 100; operations with weight 0 are left out, but at least one must
+// remain.
+func ParseOperationMix(spec string) (OperationMix, error) {
+	var mix OperationMix
+	seen := make(map[string]bool)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		name, weightStr, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%q: expected <operation>=<weight>", pair)
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(weightStr))
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("%q: weight must be a non-negative integer", pair)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("operation %q listed twice", name)
+		}
+		seen[name] = true
+
+		if weight > 0 {
+			mix = append(mix, OperationWeight{Name: name, Weight: weight})
+		}
+	}
+	if len(mix) == 0 {
+		return nil, fmt.Errorf("no operation has a positive weight")
+	}
+	return mix, nil
+}
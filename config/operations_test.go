@@ -0,0 +1,68 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseOperationMix(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    OperationMix
+		wantErr string
+	}{
+		{
+			name: "weights are kept in order",
+			spec: "read=20, insert = 50,update=30",
+			want: OperationMix{{"read", 20}, {"insert", 50}, {"update", 30}},
+		},
+		{
+			name: "zero weights are left out",
+			spec: "read=0,top_scores=3,",
+			want: OperationMix{{"top_scores", 3}},
+		},
+		{name: "empty", spec: "", wantErr: "no operation has a positive weight"},
+		{name: "only zero weights", spec: "read=0,insert=0", wantErr: "no operation has a positive weight"},
+		{name: "missing weight", spec: "read", wantErr: "expected <operation>=<weight>"},
+		{name: "missing name", spec: "=10", wantErr: "expected <operation>=<weight>"},
+		{name: "bad weight", spec: "read=lots", wantErr: "weight must be a non-negative integer"},
+		{name: "negative weight", spec: "read=-1", wantErr: "weight must be a non-negative integer"},
+		{name: "duplicate", spec: "read=10,insert=5,read=1", wantErr: `operation "read" listed twice`},
+		{name: "duplicate with zero weight", spec: "read=0,read=1", wantErr: `operation "read" listed twice`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOperationMix(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseOperationMix(%q) error = %v, want it to contain %q", tt.spec, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseOperationMix(%q) error = %v", tt.spec, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseOperationMix(%q) = %v, want %v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
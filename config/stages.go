@@ -0,0 +1,90 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoadStage is one step of a load profile. The target rate changes linearly
+// from StartRate to EndRate over Duration; equal rates hold a constant rate.
+type LoadStage struct {
+	Duration  time.Duration
+	StartRate int // Operations per second at the start of the stage
+	EndRate   int // Operations per second at the end of the stage
+}
+
+// String describes the stage, e.g. "ramp 1000->3000 ops/s for 1m0s"
+func (s LoadStage) String() string {
+	if s.StartRate == s.EndRate {
+		return fmt.Sprintf("hold %d ops/s for %v", s.StartRate, s.Duration)
+	}
+	return fmt.Sprintf("ramp %d->%d ops/s for %v", s.StartRate, s.EndRate, s.Duration)
+}
+
+// ParseLoadStages parses a comma-separated load profile. Each stage is
+// <duration>:<rate> to hold a rate or <duration>:<from>-<to> to ramp, with
+// Go durations, e.g. "1m:0-1000,10m:1000,5m:3000,1m:3000-0".
+func ParseLoadStages(profile string) ([]LoadStage, error) {
+	var stages []LoadStage
+	for _, spec := range strings.Split(profile, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		durationStr, rates, ok := strings.Cut(spec, ":")
+		if !ok {
+			return nil, fmt.Errorf("stage %q: expected <duration>:<rate> or <duration>:<from>-<to>", spec)
+		}
+		duration, err := time.ParseDuration(durationStr)
+		if err != nil {
+			return nil, fmt.Errorf("stage %q: invalid duration: %w", spec, err)
+		}
+		if duration <= 0 {
+			return nil, fmt.Errorf("stage %q: duration must be positive", spec)
+		}
+
+		fromStr, toStr, ramp := strings.Cut(rates, "-")
+		if !ramp {
+			toStr = fromStr
+		}
+		from, err := strconv.Atoi(fromStr)
+		if err != nil || from < 0 {
+			return nil, fmt.Errorf("stage %q: rate must be a non-negative integer", spec)
+		}
+		to, err := strconv.Atoi(toStr)
+		if err != nil || to < 0 {
+			return nil, fmt.Errorf("stage %q: rate must be a non-negative integer", spec)
+		}
+
+		stages = append(stages, LoadStage{Duration: duration, StartRate: from, EndRate: to})
+	}
+	return stages, nil
+}
+
+// TotalDuration returns the combined length of stages
+func TotalDuration(stages []LoadStage) time.Duration {
+	var total time.Duration
+	for _, s := range stages {
+		total += s.Duration
+	}
+	return total
+}
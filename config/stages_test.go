@@ -0,0 +1,74 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLoadStages(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		want    []LoadStage
+		wantErr string
+	}{
+		{
+			name:    "hold and ramps",
+			profile: "1m:0-1000, 10m:1000,30s:1000-0",
+			want: []LoadStage{
+				{Duration: time.Minute, StartRate: 0, EndRate: 1000},
+				{Duration: 10 * time.Minute, StartRate: 1000, EndRate: 1000},
+				{Duration: 30 * time.Second, StartRate: 1000, EndRate: 0},
+			},
+		},
+		{
+			name:    "empty stages are ignored",
+			profile: ",5s:0,",
+			want:    []LoadStage{{Duration: 5 * time.Second}},
+		},
+		{name: "empty profile", profile: "", want: nil},
+		{name: "missing rate", profile: "1m", wantErr: "expected <duration>:<rate>"},
+		{name: "bad duration", profile: "1x:100", wantErr: "invalid duration"},
+		{name: "zero duration", profile: "0s:100", wantErr: "duration must be positive"},
+		{name: "negative duration", profile: "-1m:100", wantErr: "duration must be positive"},
+		{name: "bad rate", profile: "1m:fast", wantErr: "rate must be a non-negative integer"},
+		{name: "negative rate", profile: "1m:100--5", wantErr: "rate must be a non-negative integer"},
+		{name: "missing ramp end", profile: "1m:100-", wantErr: "rate must be a non-negative integer"},
+		{name: "error names the stage", profile: "1m:100,2m:x", wantErr: `stage "2m:x"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLoadStages(tt.profile)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseLoadStages(%q) error = %v, want it to contain %q", tt.profile, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseLoadStages(%q) error = %v", tt.profile, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLoadStages(%q) = %v, want %v", tt.profile, got, tt.want)
+			}
+		})
+	}
+}
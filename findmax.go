@@ -0,0 +1,151 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/souravbiswassanto/high-write-load-client/clients/postgres"
+	"github.com/souravbiswassanto/high-write-load-client/config"
+	"github.com/souravbiswassanto/high-write-load-client/metrics"
+)
+
+// runFindMax implements the "find-max" subcommand: it runs the configured
+// workload at a fixed rate, stepping the rate up from FIND_MAX_START_RATE
+// until a step misses the SLOs, and reports the throughput/latency curve and
+// the highest sustainable throughput. It returns the process exit code
+// (0 = a sustainable rate was found, 1 = the search could not run, 2 = not
+// even the first step met the SLOs).
+func runFindMax() int {
+	fmt.Println("=================================================================")
+	fmt.Println("PostgreSQL Saturation Search")
+	fmt.Println("=================================================================")
+
+	cfg, err := config.LoadFromEnv()
+	if err != nil {
+		fmt.Printf("Error loading configuration: %v\n", err)
+		return 1
+	}
+	if err := cfg.FindMax.Validate(); err != nil {
+		fmt.Printf("Error loading configuration: %v\n", err)
+		return 1
+	}
+
+	// Every step holds one rate; the search usually ends long before the last one
+	fm := cfg.FindMax
+	var stages []config.LoadStage
+	for rate := fm.StartRate; rate <= fm.MaxRate; rate += fm.StepRate {
+		stages = append(stages, config.LoadStage{Duration: fm.StepDuration, StartRate: rate, EndRate: rate})
+	}
+	cfg.Load.Stages = stages
+	cfg.Load.Duration = config.TotalDuration(stages)
+	cfg.Adaptive.Enabled = false // The rate, not the worker count, is what is searched
+	slo := metrics.SaturationSLO{P99: fm.SLOP99, ErrorPercent: fm.SLOErrorPercent}
+
+	fmt.Println("\nConfiguration:")
+	fmt.Printf("  Database: %s@%s:%d/%s\n", cfg.DB.User, cfg.DB.Host, cfg.DB.Port, cfg.DB.DBName)
+	fmt.Printf("  Concurrent Workers: %d\n", cfg.Load.ConcurrentWriters)
+	fmt.Printf("  Workload: %s\n", cfg.Workload.Mix())
+	fmt.Printf("  Steps: %d ops/s to %d ops/s in steps of %d, %v each\n",
+		fm.StartRate, fm.MaxRate, fm.StepRate, fm.StepDuration)
+	fmt.Printf("  SLO: p99 <= %v, errors <= %.2f%%\n", slo.P99, slo.ErrorPercent)
+	fmt.Println()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	fmt.Println("Connecting to PostgreSQL...")
+	cm, err := postgres.NewConnectionManager(&cfg.DB)
+	if err != nil {
+		fmt.Printf("Failed to create connection manager: %v\n", err)
+		return 1
+	}
+	defer cm.Close()
+
+	m := metrics.NewV2()
+	if cfg.Metrics.Addr != "" {
+		go func() {
+			if err := m.Serve(ctx, cfg.Metrics.Addr); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+		}()
+	}
+
+	lg := postgres.NewLoadGeneratorV2(cm, cfg, m)
+	if err := lg.Initialize(ctx); err != nil {
+		fmt.Printf("Failed to initialize load generator: %v\n", err)
+		return 1
+	}
+
+	lg.Start(ctx)
+	m.GetSnapshot() // Start the first step's interval
+
+	// Measure each step at its end, stopping at the first one that misses the SLOs
+	report := metrics.SaturationReport{SLO: slo}
+	deadline := time.Now()
+steps:
+	for i, stage := range stages {
+		deadline = deadline.Add(stage.Duration)
+		select {
+		case <-time.After(time.Until(deadline)):
+		case <-sigChan:
+			fmt.Println("\nReceived shutdown signal, stopping the search...")
+			break steps
+		}
+
+		step := metrics.NewSaturationStep(stage.StartRate, m.GetSnapshot())
+		slo.Evaluate(&step)
+		report.Steps = append(report.Steps, step)
+
+		result := "ok"
+		if !step.Sustainable {
+			result = step.Reason
+		}
+		fmt.Printf("Step %d: target %d ops/s, achieved %.2f ops/s, p99 %v, errors %.2f%% - %s\n",
+			i+1, step.TargetRate, step.Achieved, step.WorstP99.Round(time.Microsecond), step.ErrorPercent, result)
+		if !step.Sustainable {
+			break
+		}
+	}
+
+	lg.Stop()
+	cancel()
+
+	fmt.Println()
+	report.Print()
+
+	if cfg.Workload.CleanupTable {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cleanupCancel()
+		if err := lg.Cleanup(cleanupCtx); err != nil {
+			fmt.Printf("Warning: Cleanup failed: %v\n", err)
+		}
+	}
+
+	if _, ok := report.MaxSustainable(); !ok {
+		return 2
+	}
+	return 0
+}
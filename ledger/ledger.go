@@ -0,0 +1,230 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Kind identifies the type of write an entry acknowledges
+type Kind string
+
+const (
+	// KindInsert marks a batch of acknowledged inserts
+	KindInsert Kind = "insert"
+	// KindUpdate marks acknowledged updates, with the row version each one wrote
+	KindUpdate Kind = "update"
+	// KindInDoubt marks an insert batch whose connection failed before the
+	// commit outcome arrived; its rows may or may not exist
+	KindInDoubt Kind = "in-doubt"
+)
+
+// Origin identifies the server, timeline and WAL position that acknowledged a write
+type Origin struct {
+	Server   string `json:"server,omitempty"`   // host:port reported by inet_server_addr()
+	Timeline int64  `json:"timeline,omitempty"` // Server timeline when the write was made
+	LSN      string `json:"lsn,omitempty"`      // pg_current_wal_lsn() when the write was made
+
+	// ServerTime is clock_timestamp() on the acknowledging server, used to
+	// order acknowledgements from different servers for split-brain detection
+	ServerTime time.Time `json:"server_time"`
+}
+
+// Entry is one acknowledged write batch, stored as a single JSON line
+type Entry struct {
+	RunID   string    `json:"run_id,omitempty"` // Run that wrote the entry; a ledger kept across runs holds several
+	Kind    Kind      `json:"kind"`
+	AckedAt time.Time `json:"acked_at"` // When the client received the commit acknowledgement, or the failure for in-doubt entries
+	Origin
+	IDs      []int64  `json:"ids"`
+	Versions []int64  `json:"versions,omitempty"` // Parallel to IDs for update entries
+	Keys     []string `json:"keys,omitempty"`     // Client-generated row keys, set for in-doubt entries
+}
+
+// LatestRun returns the run ID of the last entry, which is the run a ledger
+// kept across runs was last appended to
+func LatestRun(entries []Entry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	return entries[len(entries)-1].RunID
+}
+
+// Runs returns the run IDs in a ledger, in the order the runs started
+func Runs(entries []Entry) []string {
+	seen := make(map[string]bool)
+	var runs []string
+	for _, e := range entries {
+		if !seen[e.RunID] {
+			seen[e.RunID] = true
+			runs = append(runs, e.RunID)
+		}
+	}
+	return runs
+}
+
+// ForRun returns the entries written by the given run. Each run writes its
+// own table rows, and a recreated table reuses IDs, so runs must be verified
+// separately.
+func ForRun(entries []Entry, runID string) []Entry {
+	run := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		if e.RunID == runID {
+			run = append(run, e)
+		}
+	}
+	return run
+}
+
+// Writer appends acknowledged writes to an on-disk ledger file.
+// Every entry is fsync'd before Append returns, so the ledger survives
+// the load client being killed mid-test.
+type Writer struct {
+	mu   sync.Mutex
+	file *os.File
+	path string
+}
+
+// Open opens the ledger file at path for appending, creating it if needed.
+// An existing ledger keeps its entries, so a restarted run keeps adding to it;
+// only a partial final entry left behind by a killed writer is dropped.
+func Open(path string) (*Writer, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create ledger directory: %w", err)
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ledger: %w", err)
+	}
+	if err := dropPartialEntry(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to repair ledger: %w", err)
+	}
+
+	return &Writer{
+		file: file,
+		path: path,
+	}, nil
+}
+
+// dropPartialEntry truncates the file after its last newline. A file not
+// ending in a newline was cut off in the middle of an Append, whose entry was
+// never acknowledged; appending to it would merge the next entry into it.
+func dropPartialEntry(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	end := info.Size()
+	buf := make([]byte, 4096)
+	for end > 0 {
+		chunk := buf[:min(int64(len(buf)), end)]
+		if _, err := file.ReadAt(chunk, end-int64(len(chunk))); err != nil {
+			return err
+		}
+		if i := bytes.LastIndexByte(chunk, '\n'); i >= 0 {
+			end -= int64(len(chunk) - i - 1)
+			break
+		}
+		end -= int64(len(chunk))
+	}
+	if end == info.Size() {
+		return nil
+	}
+	return file.Truncate(end)
+}
+
+// Append writes an entry to the ledger and syncs it to disk
+func (w *Writer) Append(e Entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode ledger entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := w.file.Write(line); err != nil {
+		return fmt.Errorf("failed to write ledger entry: %w", err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync ledger: %w", err)
+	}
+	return nil
+}
+
+// Path returns the location of the ledger file
+func (w *Writer) Path() string {
+	return w.path
+}
+
+// Close closes the ledger file
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+// Read loads every entry from a ledger file and returns how many lines it
+// skipped. Lines that are not valid entries, such as a partial entry left by
+// a writer killed mid-append, are skipped so the entries around them can
+// still be verified.
+func Read(path string) ([]Entry, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open ledger: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	skipped := 0
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// Anything left without a trailing newline was never fully written
+			if len(bytes.TrimSpace(line)) > 0 {
+				skipped++
+			}
+			return entries, skipped, nil
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read ledger: %w", err)
+		}
+
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			skipped++
+			continue
+		}
+		entries = append(entries, e)
+	}
+}
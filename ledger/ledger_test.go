@@ -0,0 +1,146 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	entry1 = `{"kind":"insert","acked_at":"2026-01-01T00:00:00Z","server_time":"0001-01-01T00:00:00Z","ids":[1,2]}`
+	entry2 = `{"kind":"insert","acked_at":"2026-01-01T00:00:01Z","server_time":"0001-01-01T00:00:00Z","ids":[3]}`
+	torn   = `{"kind":"insert","acked_at":"2026-01-01T00:0`
+)
+
+// ledgerIDs returns the IDs of every entry, in order
+func ledgerIDs(entries []Entry) []int64 {
+	var ids []int64
+	for _, e := range entries {
+		ids = append(ids, e.IDs...)
+	}
+	return ids
+}
+
+func writeLedger(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ledger.jsonl")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		wantIDs     []int64
+		wantSkipped int
+	}{
+		{"empty", "", nil, 0},
+		{"complete", entry1 + "\n" + entry2 + "\n", []int64{1, 2, 3}, 0},
+		{"torn tail", entry1 + "\n" + torn, []int64{1, 2}, 1},
+		{"torn line followed by entries", entry1 + "\n" + torn + entry2 + "\n" + entry2 + "\n", []int64{1, 2, 3}, 1},
+		{"terminated torn line", entry1 + "\n" + torn + "\n" + entry2 + "\n", []int64{1, 2, 3}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, skipped, err := Read(writeLedger(t, tt.content))
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if got := ledgerIDs(entries); !reflect.DeepEqual(got, tt.wantIDs) {
+				t.Errorf("Read() IDs = %v, want %v", got, tt.wantIDs)
+			}
+			if skipped != tt.wantSkipped {
+				t.Errorf("Read() skipped = %d, want %d", skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestOpenDropsPartialEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"new", "", ""},
+		{"complete", entry1 + "\n", entry1 + "\n"},
+		{"torn tail", entry1 + "\n" + torn, entry1 + "\n"},
+		{"only a torn entry", torn, ""},
+		{"torn tail beyond one read chunk", entry1 + "\n" + strings.Repeat("x", 10000), entry1 + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeLedger(t, tt.content)
+			w, err := Open(path)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			if err := w.Append(Entry{Kind: KindInsert, IDs: []int64{9}}); err != nil {
+				t.Fatalf("Append() error = %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(string(data), tt.want) {
+				t.Errorf("ledger = %q, want it to start with %q", data, tt.want)
+			}
+			entries, skipped, err := Read(path)
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if skipped != 0 || entries[len(entries)-1].IDs[0] != 9 {
+				t.Errorf("Read() = %v entries, %d skipped; want the appended entry last and nothing skipped", entries, skipped)
+			}
+		})
+	}
+}
+
+func TestRuns(t *testing.T) {
+	entries := []Entry{
+		{RunID: "a", IDs: []int64{1}},
+		{RunID: "b", IDs: []int64{1}},
+		{RunID: "a", IDs: []int64{2}},
+		{RunID: "b", IDs: []int64{2}},
+	}
+	if got, want := Runs(entries), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Runs() = %v, want %v", got, want)
+	}
+	if got := LatestRun(entries); got != "b" {
+		t.Errorf("LatestRun() = %q, want %q", got, "b")
+	}
+	if got := LatestRun(nil); got != "" {
+		t.Errorf("LatestRun(nil) = %q, want \"\"", got)
+	}
+	if got, want := ledgerIDs(ForRun(entries, "a")), []int64{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ForRun(a) IDs = %v, want %v", got, want)
+	}
+	if got := ForRun(entries, "c"); len(got) != 0 {
+		t.Errorf("ForRun(c) = %v, want none", got)
+	}
+}
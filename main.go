@@ -46,7 +46,6 @@ func _main() {
 	fmt.Printf("  Concurrent Writers: %d\n", cfg.Load.ConcurrentWriters)
 	fmt.Printf("  Test Duration: %v\n", cfg.Load.Duration)
 	fmt.Printf("  Batch Size: %d records\n", cfg.Load.BatchSize)
-	fmt.Printf("  Workload: %d%% Inserts, %d%% Updates\n", cfg.Workload.InsertPercent, cfg.Workload.UpdatePercent)
 	fmt.Printf("  Report Interval: %v\n", cfg.Load.ReportInterval)
 	fmt.Println()
 
@@ -137,31 +136,20 @@ func _main() {
 	dataLossCtx, dataLossCancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer dataLossCancel()
 
-	totalInsertedIDs, lostRecords, err := lg.CheckDataLoss(dataLossCtx)
+	result, err := lg.CheckDataLoss(dataLossCtx)
 	if err != nil {
 		fmt.Printf("Warning: Data loss check failed: %v\n", err)
 	} else {
-		dataLossPercent := 0.0
-		if totalInsertedIDs > 0 {
-			dataLossPercent = float64(lostRecords) * 100.0 / float64(totalInsertedIDs)
-		}
-
-		fmt.Println("\n=================================================================")
-		fmt.Println("Data Loss Report:")
-		fmt.Println("-----------------------------------------------------------------")
-		fmt.Printf("  Total Records Inserted: %d\n", totalInsertedIDs)
-		fmt.Printf("  Records Found in DB: %d\n", totalInsertedIDs-lostRecords)
-		fmt.Printf("  Records Lost: %d\n", lostRecords)
-		fmt.Printf("  Data Loss Percentage: %.2f%%\n", dataLossPercent)
-		fmt.Println("=================================================================")
+		fmt.Println()
+		result.Print(20)
 
-		if lostRecords > 0 {
+		if lostRecords := result.LostRecords(); lostRecords > 0 {
 			fmt.Printf("\n⚠️  WARNING: %d records were inserted but not found in database!\n", lostRecords)
 			fmt.Println("This may indicate:")
 			fmt.Println("  - Database crash/restart occurred during test")
 			fmt.Println("  - pg_rewind was triggered due to network partition")
 			fmt.Println("  - Transaction rollback due to replication issues")
-		} else if totalInsertedIDs > 0 {
+		} else if result.TotalIDs > 0 {
 			fmt.Println("\n✅ No data loss detected - all inserted records are present in database")
 		}
 	}
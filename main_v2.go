@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -30,6 +31,14 @@ import (
 )
 
 func main() {
+	// Subcommands run instead of the load test
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		os.Exit(runVerify(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "find-max" {
+		os.Exit(runFindMax())
+	}
+
 	fmt.Println("=================================================================")
 	fmt.Println("PostgreSQL High Concurrency Load Testing Client v2")
 	fmt.Println("Supports Read + Write Operations for 10,000+ Concurrent Users")
@@ -44,13 +53,40 @@ func main() {
 
 	fmt.Println("\nConfiguration:")
 	fmt.Printf("  Database: %s@%s:%d/%s\n", cfg.DB.User, cfg.DB.Host, cfg.DB.Port, cfg.DB.DBName)
+	if len(cfg.DB.Hosts) > 1 {
+		fmt.Printf("  Hosts: %s (target_session_attrs=%s)\n", strings.Join(cfg.DB.Hosts, ", "), cfg.DB.TargetSessionAttrs)
+	}
+	if len(cfg.DB.ReadHosts) > 0 {
+		fmt.Printf("  Read Hosts: %s\n", strings.Join(cfg.DB.ReadHosts, ", "))
+	}
 	fmt.Printf("  Concurrent Workers: %d\n", cfg.Load.ConcurrentWriters)
 	fmt.Printf("  Test Duration: %v\n", cfg.Load.Duration)
 	fmt.Printf("  Batch Size: %d records (inserts), %d records (reads)\n",
 		cfg.Load.BatchSize, cfg.Workload.ReadBatchSize)
-	fmt.Printf("  Workload: %d%% Reads, %d%% Inserts, %d%% Updates\n",
-		cfg.Workload.ReadPercent, cfg.Workload.InsertPercent, cfg.Workload.UpdatePercent)
+	fmt.Printf("  Workload: %s\n", cfg.Workload.Mix())
 	fmt.Printf("  Report Interval: %v\n", cfg.Load.ReportInterval)
+	if len(cfg.Load.Stages) > 0 {
+		fmt.Printf("  Load Profile: %d stages (open-loop)\n", len(cfg.Load.Stages))
+	}
+	if cfg.Adaptive.Enabled {
+		fmt.Printf("  Adaptive Concurrency: p99 <= %v, errors <= %.2f%%, adjusted every %v\n",
+			cfg.Adaptive.TargetP99, cfg.Adaptive.MaxErrorPercent, cfg.Adaptive.Interval)
+	}
+	if cfg.Ledger.Path != "" {
+		fmt.Printf("  Ledger: %s\n", cfg.Ledger.Path)
+	}
+	if len(cfg.DB.ReadHosts) > 0 && cfg.Replication.HeartbeatInterval > 0 {
+		fmt.Printf("  Replication Lag: heartbeat every %v\n", cfg.Replication.HeartbeatInterval)
+	}
+	if cfg.Workload.ConsistencyCheck {
+		fmt.Println("  Consistency Check: reads check read-your-writes and monotonic reads")
+	}
+	if cfg.Metrics.Addr != "" {
+		fmt.Printf("  Metrics Endpoint: http://%s/metrics\n", cfg.Metrics.Addr)
+	}
+	if cfg.Summary.Path != "" {
+		fmt.Printf("  Run Summary: %s\n", cfg.Summary.Path)
+	}
 	fmt.Println()
 
 	// Warn if high concurrency
@@ -84,6 +120,22 @@ func main() {
 	// Initialize metrics
 	m := metrics.NewV2()
 
+	// Serve Prometheus metrics for the whole run, including the final report
+	metricsCtx, metricsCancel := context.WithCancel(context.Background())
+	defer metricsCancel()
+	if cfg.Metrics.Addr != "" {
+		go func() {
+			if err := m.Serve(metricsCtx, cfg.Metrics.Addr); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+		}()
+	}
+
+	// Show primary switches in the failover timeline
+	cm.OnSwitch(func(from, to string) {
+		m.Failover().RecordPrimarySwitch(time.Now(), from, to)
+	})
+
 	// Initialize enhanced load generator with read support
 	lg := postgres.NewLoadGeneratorV2(cm, cfg, m)
 	if err := lg.Initialize(ctx); err != nil {
@@ -97,8 +149,23 @@ func main() {
 
 	go cm.MonitorConnections(monitorCtx, 5*time.Second, func(stats *postgres.ConnectionStats) {
 		m.UpdateConnectionMetrics(stats.CurrentConnections, stats.MaxConnections, stats.AvailableConnections)
+		lg.ApplyBackpressure(stats)
 	})
 
+	// Measure the apply lag of the read hosts with heartbeats
+	var heartbeat *postgres.HeartbeatMonitor
+	if len(cm.ReadPools()) > 0 && cfg.Replication.HeartbeatInterval > 0 {
+		heartbeat = postgres.NewHeartbeatMonitor(cm.GetDB, cfg.Workload.TableName, cfg.Replication.HeartbeatInterval, m)
+		if err := heartbeat.Initialize(ctx); err != nil {
+			fmt.Printf("Failed to initialize heartbeat monitor: %v\n", err)
+			os.Exit(1)
+		}
+		for _, pool := range cm.ReadPools() {
+			heartbeat.AddReplica(pool.Name, pool.DB)
+		}
+		go heartbeat.Run(monitorCtx)
+	}
+
 	// Start metrics reporting
 	go func() {
 		ticker := time.NewTicker(cfg.Load.ReportInterval)
@@ -109,6 +176,23 @@ func main() {
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
+				for _, pool := range cm.Pools() {
+					stats := pool.DB.Stats()
+					m.UpdatePoolStats(pool.Name, metrics.PoolStats{
+						Open:      stats.OpenConnections,
+						InUse:     stats.InUse,
+						Idle:      stats.Idle,
+						WaitCount: stats.WaitCount,
+					})
+				}
+				if stage, ok := lg.CurrentStage(); ok {
+					m.UpdateStage(metrics.StageSnapshot{
+						Index:       stage.Index,
+						Count:       stage.Count,
+						Description: stage.Stage.String(),
+						TargetRate:  stage.TargetRate,
+					})
+				}
 				snapshot := m.GetSnapshot()
 				snapshot.Print()
 			}
@@ -179,44 +263,121 @@ func main() {
 	dataLossCtx, dataLossCancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer dataLossCancel()
 
-	totalInsertedIDs, lostRecords, err := lg.CheckDataLoss(dataLossCtx)
+	result, err := lg.CheckDataLoss(dataLossCtx)
 	if err != nil {
 		fmt.Printf("Warning: Data loss check failed: %v\n", err)
 	} else {
-		dataLossPercent := 0.0
-		if totalInsertedIDs > 0 {
-			dataLossPercent = float64(lostRecords) * 100.0 / float64(totalInsertedIDs)
-		}
-
-		fmt.Println("\n=================================================================")
-		fmt.Println("Data Loss Report:")
-		fmt.Println("-----------------------------------------------------------------")
-		fmt.Printf("  Total Records Inserted: %d\n", totalInsertedIDs)
-		fmt.Printf("  Records Found in DB: %d\n", totalInsertedIDs-lostRecords)
-		fmt.Printf("  Records Lost: %d\n", lostRecords)
-		fmt.Printf("  Data Loss Percentage: %.2f%%\n", dataLossPercent)
-		fmt.Println("=================================================================")
+		fmt.Println()
+		result.Print(20)
 
-		if lostRecords > 0 {
+		if lostRecords := result.LostRecords(); lostRecords > 0 {
 			fmt.Printf("\n⚠️  WARNING: %d records were inserted but not found in database!\n", lostRecords)
 			fmt.Println("This may indicate:")
 			fmt.Println("  - Database crash/restart occurred during test")
 			fmt.Println("  - pg_rewind was triggered due to network partition")
 			fmt.Println("  - Transaction rollback due to replication issues")
-		} else if totalInsertedIDs > 0 {
+		} else if result.TotalIDs > 0 {
 			fmt.Println("\n✅ No data loss detected - all inserted records are present in database")
 		}
+		if corrupted := len(result.CorruptedIDs); corrupted > 0 {
+			fmt.Printf("\n⚠️  WARNING: %d records survived with a payload that no longer matches its checksum!\n", corrupted)
+		}
+		if lostUpdates := len(result.LostUpdates); lostUpdates > 0 {
+			fmt.Printf("\n⚠️  WARNING: %d rows lost an acknowledged update (rolled back to an older version)!\n", lostUpdates)
+		}
+		if duplicates := len(result.DuplicateKeys); duplicates > 0 {
+			fmt.Printf("\n⚠️  WARNING: %d client keys were written to more than one row!\n", duplicates)
+		}
+
+		m.RecordDataLoss(metrics.DataLoss{
+			Acknowledged: result.TotalIDs,
+			Lost:         result.LostRecords(),
+			Corrupted:    int64(len(result.CorruptedIDs)),
+			LostUpdates:  int64(len(result.LostUpdates)),
+
+			DuplicateKeys: int64(len(result.DuplicateKeys)),
+		})
+	}
+
+	fmt.Println()
+	m.SplitBrain().Print()
+
+	if heartbeat != nil {
+		fmt.Println()
+		m.Replication().Print()
 	}
+	if cfg.Workload.ConsistencyCheck {
+		fmt.Println()
+		m.Consistency().Print()
+	}
+
+	// RPO can only be measured when the data loss check succeeded
+	fmt.Println()
+	failover := lg.FailoverReport(result)
+	failover.Print()
 
 	// Cleanup test data table after test completion
-	fmt.Println("\nCleaning up test data...")
-	cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cleanupCancel()
-	if err := lg.Cleanup(cleanupCtx); err != nil {
-		fmt.Printf("Warning: Cleanup failed: %v\n", err)
+	if cfg.Workload.CleanupTable {
+		fmt.Println("\nCleaning up test data...")
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cleanupCancel()
+		if err := lg.Cleanup(cleanupCtx); err != nil {
+			fmt.Printf("Warning: Cleanup failed: %v\n", err)
+		} else {
+			fmt.Println("Test data table deleted successfully")
+		}
+		if heartbeat != nil {
+			if err := heartbeat.Cleanup(cleanupCtx); err != nil {
+				fmt.Printf("Warning: Heartbeat cleanup failed: %v\n", err)
+			}
+		}
 	} else {
-		fmt.Println("Test data table deleted successfully")
+		fmt.Printf("\nKeeping table %s for later verification\n", cfg.Workload.TableName)
 	}
 
-	fmt.Println("\nTest completed successfully!")
+	// Decide whether the run passed and record it for automation
+	summary := m.Summary(finalSnapshot)
+	redacted := *cfg
+	redacted.DB.Password = ""
+	summary.Config = redacted
+	versionCtx, versionCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer versionCancel()
+	if version, err := cm.ServerVersion(versionCtx); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	} else {
+		summary.ServerVersion = version
+	}
+	metrics.Assertions{
+		MaxDataLoss:     cfg.Summary.MaxDataLoss,
+		MaxP99:          cfg.Summary.MaxP99,
+		MaxErrorPercent: cfg.Summary.MaxErrorPercent,
+	}.Check(&summary)
+	fmt.Println()
+	summary.Print()
+	if cfg.Summary.Path != "" {
+		if err := summary.Write(cfg.Summary.Path); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		} else {
+			fmt.Printf("Run summary written to %s\n", cfg.Summary.Path)
+		}
+	}
+
+	if summary.Passed {
+		fmt.Println("\nTest completed successfully!")
+	} else {
+		fmt.Println("\nTest failed: assertions not met")
+	}
+
+	if cfg.Metrics.Addr != "" && cfg.Metrics.Linger > 0 {
+		fmt.Printf("Serving final metrics for %v\n", cfg.Metrics.Linger)
+		select {
+		case <-time.After(cfg.Metrics.Linger):
+		case <-sigChan:
+		}
+	}
+
+	// A failed run exits non-zero so that a Kubernetes Job is marked failed
+	if !summary.Passed {
+		os.Exit(2)
+	}
 }
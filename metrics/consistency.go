@@ -0,0 +1,132 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConsistencyTracker counts read-your-writes and monotonic-read checks made
+// against replicas
+type ConsistencyTracker struct {
+	checks     atomic.Int64
+	staleReads atomic.Int64
+	violations atomic.Int64 // Monotonic-read violations
+
+	staleness *Histogram // Ages of the writes stale reads missed
+
+	mu         sync.Mutex
+	perReplica map[string]*ReplicaConsistency
+}
+
+// ReplicaConsistency counts the checks made against one replica
+type ReplicaConsistency struct {
+	Replica    string
+	Checks     int64
+	StaleReads int64
+	Violations int64
+}
+
+// NewConsistencyTracker creates an empty tracker
+func NewConsistencyTracker() *ConsistencyTracker {
+	return &ConsistencyTracker{
+		staleness:  NewHistogram(),
+		perReplica: make(map[string]*ReplicaConsistency),
+	}
+}
+
+// RecordCheck records one check against replica. A stale read is one that did
+// not see the session's latest acknowledged write, which was acknowledged
+// staleness ago; a violation is one that saw less than an earlier read.
+func (t *ConsistencyTracker) RecordCheck(replica string, stale bool, staleness time.Duration, violation bool) {
+	t.checks.Add(1)
+	if stale {
+		t.staleReads.Add(1)
+		t.staleness.Record(staleness)
+	}
+	if violation {
+		t.violations.Add(1)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	r, ok := t.perReplica[replica]
+	if !ok {
+		r = &ReplicaConsistency{Replica: replica}
+		t.perReplica[replica] = r
+	}
+	r.Checks++
+	if stale {
+		r.StaleReads++
+	}
+	if violation {
+		r.Violations++
+	}
+}
+
+// Totals returns the number of checks, stale reads and monotonic-read violations
+func (t *ConsistencyTracker) Totals() (checks, staleReads, violations int64) {
+	return t.checks.Load(), t.staleReads.Load(), t.violations.Load()
+}
+
+// Replicas returns the per-replica counts, sorted by replica
+func (t *ConsistencyTracker) Replicas() []ReplicaConsistency {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	replicas := make([]ReplicaConsistency, 0, len(t.perReplica))
+	for _, r := range t.perReplica {
+		replicas = append(replicas, *r)
+	}
+	sort.Slice(replicas, func(i, j int) bool { return replicas[i].Replica < replicas[j].Replica })
+	return replicas
+}
+
+// Print prints stale-read rates, the staleness distribution and monotonic-read violations
+func (t *ConsistencyTracker) Print() {
+	checks, staleReads, violations := t.Totals()
+
+	fmt.Println("=================================================================")
+	fmt.Println("Replica Consistency Report:")
+	fmt.Println("-----------------------------------------------------------------")
+	fmt.Printf("  Checks: %d\n", checks)
+	fmt.Printf("  Stale Reads (latest own write not visible): %d (%.2f%%)\n", staleReads, percentOf(staleReads, checks))
+	fmt.Printf("  Monotonic-Read Violations: %d\n", violations)
+
+	if staleness := t.staleness.Snapshot().Stats(); staleness.Count > 0 {
+		fmt.Printf("  Staleness - %s\n", staleness)
+	}
+
+	for _, r := range t.Replicas() {
+		fmt.Printf("  %s: %d checks, %d stale (%.2f%%), %d violations\n",
+			r.Replica, r.Checks, r.StaleReads, percentOf(r.StaleReads, r.Checks), r.Violations)
+	}
+	fmt.Println("=================================================================")
+}
+
+// percentOf returns part as a percentage of total
+func percentOf(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) * 100 / float64(total)
+}
@@ -0,0 +1,129 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Phases of an operation a failure can happen in
+const (
+	PhaseConnect = "connect" // Opening a server connection failed; nothing was sent
+	PhaseExecute = "execute" // The statement failed or was rejected
+	PhaseCommit  = "commit"  // The connection was lost with the write's commit outcome unknown
+	PhaseScan    = "scan"    // Reading the statement's results failed
+	PhaseLedger  = "ledger"  // The write succeeded but could not be recorded in the ledger
+)
+
+// maxPrintedErrorKinds caps the error kinds listed in the periodic report
+const maxPrintedErrorKinds = 10
+
+// ErrorKind identifies a class of failed operations
+type ErrorKind struct {
+	Op    string // read, insert or update
+	Phase string
+	Code  string // SQLSTATE, or a client-side category such as "network" when the server sent none
+	Name  string // Condition name of the SQLSTATE, or a short description
+}
+
+// String formats the kind for the periodic report
+func (k ErrorKind) String() string {
+	return fmt.Sprintf("%s/%s %s %s", k.Op, k.Phase, k.Code, k.Name)
+}
+
+// ErrorCount is how often one kind of failure happened
+type ErrorCount struct {
+	ErrorKind
+	Total    int64
+	Interval int64 // Since the previous snapshot
+}
+
+// ErrorTracker counts failed operations per kind
+type ErrorTracker struct {
+	mu     sync.Mutex
+	counts map[ErrorKind]*errorCounters
+}
+
+// errorCounters accumulates the failures of one kind
+type errorCounters struct {
+	total int64
+	last  int64 // Total at the previous snapshot
+}
+
+// NewErrorTracker creates an empty tracker
+func NewErrorTracker() *ErrorTracker {
+	return &ErrorTracker{
+		counts: make(map[ErrorKind]*errorCounters),
+	}
+}
+
+// Record records a failed operation
+func (t *ErrorTracker) Record(kind ErrorKind) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, ok := t.counts[kind]
+	if !ok {
+		c = &errorCounters{}
+		t.counts[kind] = c
+	}
+	c.total++
+}
+
+// Snapshot returns the count of every kind, most frequent first, and starts a new interval
+func (t *ErrorTracker) Snapshot() []ErrorCount {
+	return t.snapshot(true)
+}
+
+// Current returns the count of every kind, most frequent first, without starting a new interval
+func (t *ErrorTracker) Current() []ErrorCount {
+	return t.snapshot(false)
+}
+
+// snapshot collects every kind's counts; advance starts a new interval
+func (t *ErrorTracker) snapshot(advance bool) []ErrorCount {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counts := make([]ErrorCount, 0, len(t.counts))
+	for kind, c := range t.counts {
+		counts = append(counts, ErrorCount{ErrorKind: kind, Total: c.total, Interval: c.total - c.last})
+		if advance {
+			c.last = c.total
+		}
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Total != counts[j].Total {
+			return counts[i].Total > counts[j].Total
+		}
+		return counts[i].ErrorKind.String() < counts[j].ErrorKind.String()
+	})
+	return counts
+}
+
+// printErrorCounts prints the most frequent error kinds of a snapshot
+func printErrorCounts(counts []ErrorCount) {
+	for i, c := range counts {
+		if i == maxPrintedErrorKinds {
+			fmt.Printf("  ... and %d more kinds\n", len(counts)-i)
+			break
+		}
+		fmt.Printf("  %-60s interval %d, total %d\n", c.ErrorKind, c.Interval, c.Total)
+	}
+}
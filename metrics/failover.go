@@ -0,0 +1,258 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// minReportedOutage hides isolated write errors that are not an outage.
+// Shorter windows are still reported when the writes resumed on another server.
+const minReportedOutage = time.Second
+
+// FailoverAnalyzer follows the outcome of every write and finds the windows in
+// which the database was unavailable for writes: from the first failing write
+// to the first write that was issued after it and succeeded.
+type FailoverAnalyzer struct {
+	mu         sync.Mutex
+	lastServer string    // Server that acknowledged the most recent successful write
+	lastAck    time.Time // When that write was acknowledged
+	current    *Outage   // Open outage, nil while writes succeed
+	outages    []Outage
+	switches   []PrimarySwitch
+}
+
+// Outage is one window of write unavailability
+type Outage struct {
+	Start     time.Time // First failing write
+	End       time.Time // First successful write issued after Start; zero if never recovered
+	LastAck   time.Time // Last acknowledgement before the outage
+	OldServer string    // Server acknowledging writes before the outage
+	NewServer string    // Server acknowledging writes after the outage
+	Errors    int64     // Failed writes during the outage
+}
+
+// PrimarySwitch is a move of the client's writes from one host to another,
+// made when the host it was writing to no longer matched the target session attributes
+type PrimarySwitch struct {
+	At   time.Time
+	From string
+	To   string
+}
+
+// LostWrite is an acknowledged write batch that data loss verification did not find
+type LostWrite struct {
+	AckedAt time.Time
+	Rows    int64
+}
+
+// FailoverEvent is an outage together with its recovery time and the data lost with it
+type FailoverEvent struct {
+	Outage
+	RTO time.Duration // Length of the outage; up to the end of the run if never recovered
+
+	// RPO is measured over the acknowledged writes that were lost between
+	// the previous outage and this one: RPORows is how many rows, RPOTime how
+	// far back before the outage the oldest of them was acknowledged
+	RPORows int64
+	RPOTime time.Duration
+}
+
+// FailoverReport summarizes every outage of a run
+type FailoverReport struct {
+	Events        []FailoverEvent
+	RPOMeasured   bool  // False when data loss verification did not run
+	UnmatchedLoss int64 // Lost rows that cannot be attributed to an outage
+	Switches      []PrimarySwitch
+}
+
+// NewFailoverAnalyzer creates an empty analyzer
+func NewFailoverAnalyzer() *FailoverAnalyzer {
+	return &FailoverAnalyzer{
+		outages: make([]Outage, 0),
+	}
+}
+
+// RecordWriteSuccess records a write issued at start that server acknowledged
+// at ackedAt. Writes issued before the current outage began do not prove the
+// database is available again, so they never end it.
+func (a *FailoverAnalyzer) RecordWriteSuccess(start, ackedAt time.Time, server string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.current != nil && !start.Before(a.current.Start) {
+		a.current.End = ackedAt
+		a.current.NewServer = server
+		a.outages = append(a.outages, *a.current)
+		a.current = nil
+	}
+	if ackedAt.After(a.lastAck) {
+		a.lastAck = ackedAt
+		a.lastServer = server
+	}
+}
+
+// RecordWriteFailure records a write that failed at the given time
+func (a *FailoverAnalyzer) RecordWriteFailure(at time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.current == nil {
+		a.current = &Outage{
+			Start:     at,
+			LastAck:   a.lastAck,
+			OldServer: a.lastServer,
+		}
+	}
+	a.current.Errors++
+}
+
+// RecordPrimarySwitch records that the client moved its writes from one host to another
+func (a *FailoverAnalyzer) RecordPrimarySwitch(at time.Time, from, to string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.switches = append(a.switches, PrimarySwitch{At: at, From: from, To: to})
+}
+
+// Switches returns every recorded primary switch in order
+func (a *FailoverAnalyzer) Switches() []PrimarySwitch {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switches := make([]PrimarySwitch, len(a.switches))
+	copy(switches, a.switches)
+	return switches
+}
+
+// Outages returns every outage worth reporting in order, including one still in progress
+func (a *FailoverAnalyzer) Outages() []Outage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	outages := make([]Outage, 0, len(a.outages)+1)
+	for _, o := range a.outages {
+		if o.End.Sub(o.Start) >= minReportedOutage || o.NewServer != o.OldServer {
+			outages = append(outages, o)
+		}
+	}
+	if a.current != nil {
+		outages = append(outages, *a.current)
+	}
+	return outages
+}
+
+// Report computes RTO for every outage and, when lost is not nil, attributes
+// each lost write to the first outage that followed its acknowledgement
+func (a *FailoverAnalyzer) Report(lost []LostWrite) FailoverReport {
+	outages := a.Outages()
+	now := time.Now()
+
+	report := FailoverReport{
+		Events:      make([]FailoverEvent, 0, len(outages)),
+		RPOMeasured: lost != nil,
+		Switches:    a.Switches(),
+	}
+	for _, o := range outages {
+		end := o.End
+		if end.IsZero() {
+			end = now
+		}
+		report.Events = append(report.Events, FailoverEvent{Outage: o, RTO: end.Sub(o.Start)})
+	}
+
+	sorted := make([]LostWrite, len(lost))
+	copy(sorted, lost)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].AckedAt.Before(sorted[j].AckedAt) })
+
+	next := 0
+	for _, w := range sorted {
+		for next < len(report.Events) && !w.AckedAt.Before(report.Events[next].Start) {
+			next++
+		}
+		if next == len(report.Events) {
+			report.UnmatchedLoss += w.Rows
+			continue
+		}
+		e := &report.Events[next]
+		if e.RPORows == 0 {
+			e.RPOTime = e.Start.Sub(w.AckedAt)
+		}
+		e.RPORows += w.Rows
+	}
+	return report
+}
+
+// Print prints the failover timeline with RTO and RPO for every outage
+func (r *FailoverReport) Print() {
+	fmt.Println("=================================================================")
+	fmt.Println("Failover Report:")
+	fmt.Println("-----------------------------------------------------------------")
+	for _, sw := range r.Switches {
+		fmt.Printf("  %s: writes switched from %s to %s\n", sw.At.Format(time.RFC3339Nano), sw.From, sw.To)
+	}
+	if len(r.Switches) > 0 {
+		fmt.Println("-----------------------------------------------------------------")
+	}
+	if len(r.Events) == 0 {
+		fmt.Println("  No write outages detected")
+		fmt.Println("=================================================================")
+		return
+	}
+
+	for i, e := range r.Events {
+		fmt.Printf("  Outage %d: %s", i+1, e.Start.Format(time.RFC3339Nano))
+		if e.End.IsZero() {
+			fmt.Println(" - not recovered by end of run")
+		} else {
+			fmt.Printf(" to %s\n", e.End.Format(time.RFC3339Nano))
+		}
+		fmt.Printf("    Failed writes: %d\n", e.Errors)
+		if !e.LastAck.IsZero() {
+			fmt.Printf("    Last acknowledgement before outage: %s\n", e.LastAck.Format(time.RFC3339Nano))
+		}
+		switch {
+		case e.End.IsZero():
+			fmt.Printf("    Writes last acknowledged by: %s\n", serverOrUnknown(e.OldServer))
+		case e.NewServer != e.OldServer:
+			fmt.Printf("    Primary changed: %s -> %s\n", serverOrUnknown(e.OldServer), serverOrUnknown(e.NewServer))
+		default:
+			fmt.Printf("    Same server recovered: %s\n", serverOrUnknown(e.NewServer))
+		}
+		fmt.Printf("    RTO: %v\n", e.RTO.Round(time.Millisecond))
+		if r.RPOMeasured {
+			fmt.Printf("    RPO: %d rows, %v\n", e.RPORows, e.RPOTime.Round(time.Millisecond))
+		} else {
+			fmt.Println("    RPO: not measured (data loss check did not run)")
+		}
+	}
+	if r.UnmatchedLoss > 0 {
+		fmt.Println("-----------------------------------------------------------------")
+		fmt.Printf("  %d lost rows were acknowledged after the last outage\n", r.UnmatchedLoss)
+	}
+	fmt.Println("=================================================================")
+}
+
+// serverOrUnknown returns server, or a placeholder when it was never captured
+func serverOrUnknown(server string) string {
+	if server == "" {
+		return "unknown server"
+	}
+	return server
+}
@@ -0,0 +1,229 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// Histogram layout: values below 2^subBucketBits get a bucket each, and every
+// power of two above that is split into 2^(subBucketBits-1) equal buckets, so
+// a bucket is never wider than 1/128 of its values (under 0.8% error), as in
+// an HDR histogram with two significant digits.
+const (
+	subBucketBits  = 8
+	subBucketCount = 1 << subBucketBits  // Buckets below the first split
+	subBucketHalf  = subBucketCount >> 1 // Buckets per power of two above it
+
+	// maxTrackable is the largest value that gets its own bucket, about 18
+	// minutes in nanoseconds; larger values are counted as maxTrackable
+	maxTrackable = 1<<40 - 1
+)
+
+// bucketCount is the number of buckets needed up to maxTrackable
+var bucketCount = bucketIndex(maxTrackable) + 1
+
+// Histogram is a fixed-size, lock-free latency histogram. Recording is a few
+// atomic adds, so any number of workers can share one, and snapshots can be
+// taken at any time without stalling them.
+type Histogram struct {
+	counts []atomic.Int64
+	total  atomic.Int64
+	sum    atomic.Int64 // Nanoseconds
+	max    atomic.Int64 // Nanoseconds
+}
+
+// HistogramSnapshot is a point-in-time copy of a histogram. Snapshots can be
+// merged, e.g. across workers or pools, and subtracted to get an interval.
+type HistogramSnapshot struct {
+	counts []int64
+	total  int64
+	sum    int64
+	max    int64
+}
+
+// LatencyStats summarizes a latency distribution
+type LatencyStats struct {
+	Count int64
+	Avg   time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+	P999  time.Duration
+	Max   time.Duration
+}
+
+// NewHistogram creates an empty histogram
+func NewHistogram() *Histogram {
+	return &Histogram{
+		counts: make([]atomic.Int64, bucketCount),
+	}
+}
+
+// bucketIndex returns the bucket counting v
+func bucketIndex(v int64) int {
+	if v < subBucketCount {
+		return int(v)
+	}
+	shift := bits.Len64(uint64(v)) - subBucketBits
+	mantissa := int(v >> shift) // In [subBucketHalf, subBucketCount)
+	return subBucketCount + (shift-1)*subBucketHalf + mantissa - subBucketHalf
+}
+
+// bucketHighest returns the largest value counted in bucket i
+func bucketHighest(i int) int64 {
+	if i < subBucketCount {
+		return int64(i)
+	}
+	shift := (i-subBucketCount)/subBucketHalf + 1
+	mantissa := int64((i-subBucketCount)%subBucketHalf + subBucketHalf)
+	return (mantissa+1)<<shift - 1
+}
+
+// Record adds one observation
+func (h *Histogram) Record(d time.Duration) {
+	v := int64(d)
+	if v < 0 {
+		v = 0
+	}
+	if v > maxTrackable {
+		v = maxTrackable
+	}
+
+	h.counts[bucketIndex(v)].Add(1)
+	h.total.Add(1)
+	h.sum.Add(v)
+	for {
+		current := h.max.Load()
+		if v <= current || h.max.CompareAndSwap(current, v) {
+			break
+		}
+	}
+}
+
+// Snapshot copies the current counts. Observations recorded while copying
+// may be partly included, which is fine for reporting.
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	s := HistogramSnapshot{
+		counts: make([]int64, len(h.counts)),
+		total:  h.total.Load(),
+		sum:    h.sum.Load(),
+		max:    h.max.Load(),
+	}
+	for i := range h.counts {
+		s.counts[i] = h.counts[i].Load()
+	}
+	return s
+}
+
+// Merge returns the combined distribution of s and other
+func (s HistogramSnapshot) Merge(other HistogramSnapshot) HistogramSnapshot {
+	merged := HistogramSnapshot{
+		counts: make([]int64, bucketCount),
+		total:  s.total + other.total,
+		sum:    s.sum + other.sum,
+		max:    max(s.max, other.max),
+	}
+	for i := range merged.counts {
+		if i < len(s.counts) {
+			merged.counts[i] += s.counts[i]
+		}
+		if i < len(other.counts) {
+			merged.counts[i] += other.counts[i]
+		}
+	}
+	return merged
+}
+
+// Sub returns the observations recorded since prev, an earlier snapshot of
+// the same histogram. The maximum is the upper bound of the highest bucket
+// that received observations in between.
+func (s HistogramSnapshot) Sub(prev HistogramSnapshot) HistogramSnapshot {
+	diff := HistogramSnapshot{
+		counts: make([]int64, len(s.counts)),
+		total:  s.total - prev.total,
+		sum:    s.sum - prev.sum,
+	}
+	for i := range s.counts {
+		diff.counts[i] = s.counts[i]
+		if i < len(prev.counts) {
+			diff.counts[i] -= prev.counts[i]
+		}
+		if diff.counts[i] > 0 {
+			diff.max = min(bucketHighest(i), s.max)
+		}
+	}
+	return diff
+}
+
+// Count returns the number of observations
+func (s HistogramSnapshot) Count() int64 {
+	return s.total
+}
+
+// Percentile returns the value below which p percent of the observations
+// fall, reported as the upper bound of its bucket and never above the maximum
+func (s HistogramSnapshot) Percentile(p float64) time.Duration {
+	var seen int64
+	for _, c := range s.counts {
+		seen += c
+	}
+	if seen == 0 {
+		return 0
+	}
+
+	rank := int64(float64(seen)*p/100 + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	var cumulative int64
+	for i, c := range s.counts {
+		cumulative += c
+		if cumulative >= rank {
+			return time.Duration(min(bucketHighest(i), s.max))
+		}
+	}
+	return time.Duration(s.max)
+}
+
+// Stats summarizes the distribution
+func (s HistogramSnapshot) Stats() LatencyStats {
+	if s.total <= 0 {
+		return LatencyStats{}
+	}
+	return LatencyStats{
+		Count: s.total,
+		Avg:   time.Duration(s.sum / s.total),
+		P50:   s.Percentile(50),
+		P90:   s.Percentile(90),
+		P95:   s.Percentile(95),
+		P99:   s.Percentile(99),
+		P999:  s.Percentile(99.9),
+		Max:   time.Duration(s.max),
+	}
+}
+
+// String formats the summary for the periodic report
+func (l LatencyStats) String() string {
+	return fmt.Sprintf("Avg: %v, P50: %v, P90: %v, P99: %v, P99.9: %v, Max: %v",
+		l.Avg.Round(time.Microsecond), l.P50.Round(time.Microsecond), l.P90.Round(time.Microsecond),
+		l.P99.Round(time.Microsecond), l.P999.Round(time.Microsecond), l.Max.Round(time.Microsecond))
+}
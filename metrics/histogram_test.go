@@ -0,0 +1,151 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketBoundaries(t *testing.T) {
+	tests := []struct {
+		value   int64
+		index   int
+		highest int64 // Upper bound of the value's bucket
+	}{
+		{0, 0, 0},
+		{255, 255, 255},
+		{256, 256, 257},
+		{257, 256, 257},
+		{258, 257, 259},
+		{511, 383, 511},
+		{512, 384, 515},
+		{515, 384, 515},
+		{516, 385, 519},
+		{maxTrackable, bucketCount - 1, maxTrackable},
+	}
+	for _, tt := range tests {
+		if got := bucketIndex(tt.value); got != tt.index {
+			t.Errorf("bucketIndex(%d) = %d, want %d", tt.value, got, tt.index)
+		}
+		if got := bucketHighest(tt.index); got != tt.highest {
+			t.Errorf("bucketHighest(%d) = %d, want %d", tt.index, got, tt.highest)
+		}
+	}
+}
+
+func TestBucketsAreContiguous(t *testing.T) {
+	for i := 0; i < bucketCount-1; i++ {
+		highest := bucketHighest(i)
+		if got := bucketIndex(highest); got != i {
+			t.Fatalf("bucketIndex(bucketHighest(%d)) = %d", i, got)
+		}
+		if got := bucketIndex(highest + 1); got != i+1 {
+			t.Fatalf("bucketIndex(bucketHighest(%d)+1) = %d, want %d", i, got, i+1)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	// 1ns to 100ns once each, all below the first split, so every value is exact
+	exact := NewHistogram()
+	for v := 1; v <= 100; v++ {
+		exact.Record(time.Duration(v))
+	}
+	// 1µs to 10ms in 1µs steps, so percentiles are bucket bounds
+	wide := NewHistogram()
+	for v := 1; v <= 10000; v++ {
+		wide.Record(time.Duration(v) * time.Microsecond)
+	}
+
+	tests := []struct {
+		name string
+		h    *Histogram
+		p    float64
+		want time.Duration
+	}{
+		{"exact p0", exact, 0, 1},
+		{"exact p50", exact, 50, 50},
+		{"exact p90", exact, 90, 90},
+		{"exact p99", exact, 99, 99},
+		{"exact p100", exact, 100, 100},
+		{"wide p50", wide, 50, 5 * time.Millisecond},
+		{"wide p99", wide, 99, 9900 * time.Microsecond},
+		{"wide p99.9", wide, 99.9, 9990 * time.Microsecond},
+		{"wide p100", wide, 100, 10 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.h.Snapshot().Percentile(tt.p)
+			// Reported values are bucket upper bounds, at most 1/128 above the true value
+			if got < tt.want || float64(got-tt.want) > float64(tt.want)/128 {
+				t.Errorf("Percentile(%v) = %v, want %v within 1/128 above", tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPercentileEdges(t *testing.T) {
+	h := NewHistogram()
+	if got := h.Snapshot().Percentile(99); got != 0 {
+		t.Errorf("Percentile() of an empty histogram = %v, want 0", got)
+	}
+
+	h.Record(-time.Second)
+	h.Record(time.Hour)
+	s := h.Snapshot()
+	if got := s.Percentile(0); got != 0 {
+		t.Errorf("Percentile(0) = %v, want negative values counted as 0", got)
+	}
+	if got := s.Percentile(100); got != maxTrackable {
+		t.Errorf("Percentile(100) = %v, want values above maxTrackable counted as %v", got, time.Duration(maxTrackable))
+	}
+}
+
+func TestSub(t *testing.T) {
+	h := NewHistogram()
+	h.Record(time.Second)
+	for i := 0; i < 10; i++ {
+		h.Record(100)
+	}
+	prev := h.Snapshot()
+
+	for i := 0; i < 3; i++ {
+		h.Record(300)
+	}
+	h.Record(200)
+	diff := h.Snapshot().Sub(prev)
+
+	if got := diff.Count(); got != 4 {
+		t.Errorf("Count() = %d, want 4", got)
+	}
+	stats := diff.Stats()
+	if stats.Avg != 275 {
+		t.Errorf("Avg = %v, want 275ns", stats.Avg)
+	}
+	if stats.P50 != time.Duration(bucketHighest(bucketIndex(300))) {
+		t.Errorf("P50 = %v, want the bucket of 300ns", stats.P50)
+	}
+	// The maximum comes from the interval's buckets, not the earlier 1s
+	if stats.Max != time.Duration(bucketHighest(bucketIndex(300))) {
+		t.Errorf("Max = %v, want the bucket of 300ns", stats.Max)
+	}
+
+	if empty := h.Snapshot().Sub(h.Snapshot()); empty.Count() != 0 || empty.Stats().Max != 0 {
+		t.Errorf("Sub() of equal snapshots = %+v, want no observations", empty.Stats())
+	}
+}
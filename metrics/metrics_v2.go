@@ -18,9 +18,12 @@ package metrics
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // MetricsV2 tracks all performance metrics including read operations
@@ -30,17 +33,68 @@ type MetricsV2 struct {
 	totalInserts atomic.Int64
 	totalUpdates atomic.Int64
 	totalErrors  atomic.Int64
+	totalInDoubt atomic.Int64 // Errors that left the commit outcome unknown
 	totalBytes   atomic.Int64
 
+	// Retry tracking
+	totalRetries          atomic.Int64
+	firstAttemptSuccesses atomic.Int64 // Operations that succeeded without a retry
+	retriedSuccesses      atomic.Int64 // Operations that succeeded after at least one retry
+	retriesExhausted      atomic.Int64 // Operations that failed on their last allowed attempt
+
 	// Data loss tracking
 	insertedIDs      sync.Map // map[int64]bool - tracks all inserted IDs
 	totalInsertedIDs atomic.Int64
+	dataLoss         atomic.Pointer[DataLoss] // Outcome of data loss verification, nil until it ran
+
+	// Split-brain tracking
+	splitBrain *SplitBrainDetector
+
+	// Write availability tracking
+	failover *FailoverAnalyzer
+
+	// Replica apply lag from the heartbeat monitor
+	replication *ReplicationLagTracker
+
+	// Read-your-writes and monotonic-read checks against replicas
+	consistency *ConsistencyTracker
+
+	// Per connection pool activity
+	pools *PoolTracker
+
+	// Failed operations by operation, phase and SQLSTATE
+	errors *ErrorTracker
+
+	// Retried attempts by the same classification
+	retries *ErrorTracker
+
+	// Operations other than reads, inserts and updates, by name
+	operations *OperationTracker
+
+	// Latency tracking over the whole run
+	readLatency   *Histogram
+	insertLatency *Histogram
+	updateLatency *Histogram
+
+	// How far behind the fixed-rate schedule operations started
+	startDelay *Histogram
+
+	// Position in the staged load profile, nil when running closed-loop
+	stage atomic.Pointer[StageSnapshot]
+
+	// State of the free connection backpressure, nil until the connection monitor reported
+	backpressure atomic.Pointer[BackpressureSnapshot]
+
+	// Histogram state at the previous snapshot, for per-interval percentiles
+	lastReadLatency   HistogramSnapshot
+	lastInsertLatency HistogramSnapshot
+	lastUpdateLatency HistogramSnapshot
+
+	// Full latency distribution per operation for the /metrics endpoint
+	latencyHistogram *prometheus.HistogramVec
 
-	// Latency tracking
-	readLatencies   []time.Duration
-	insertLatencies []time.Duration
-	updateLatencies []time.Duration
-	latencyMutex    sync.RWMutex
+	// Workers currently issuing operations
+	activeWorkers atomic.Int32
 
 	// Connection metrics
 	activeConns    atomic.Int32
@@ -55,6 +109,7 @@ type MetricsV2 struct {
 	lastReadCount   int64
 	lastInsertCount int64
 	lastUpdateCount int64
+	lastOtherCount  int64 // Operations other than reads, inserts and updates
 	lastErrorCount  int64
 	lastBytesCount  int64
 }
@@ -67,8 +122,15 @@ type MetricsSnapshotV2 struct {
 	TotalUpdates    int64
 	TotalOperations int64
 	TotalErrors     int64
+	TotalInDoubt    int64
 	TotalBytes      int64
 
+	// Retries, zero when none were needed
+	TotalRetries          int64
+	FirstAttemptSuccesses int64
+	RetriedSuccesses      int64
+	RetriesExhausted      int64
+
 	// Data loss tracking
 	TotalInsertedIDs int64
 	LostRecords      int64
@@ -81,31 +143,65 @@ type MetricsSnapshotV2 struct {
 	ErrorsPerSec  float64
 	BytesPerSec   float64
 
-	AvgReadLatency time.Duration
-	P95ReadLatency time.Duration
-	P99ReadLatency time.Duration
+	// Latency distributions over the whole run
+	ReadLatency   LatencyStats
+	InsertLatency LatencyStats
+	UpdateLatency LatencyStats
+
+	// Latency distributions since the previous snapshot
+	IntervalLatency       LatencyStats // All operation types together
+	IntervalReadLatency   LatencyStats
+	IntervalInsertLatency LatencyStats
+	IntervalUpdateLatency LatencyStats
+
+	// How far behind their intended start operations began, zero when not running at a fixed rate
+	StartDelay LatencyStats
+
+	// Operations other than reads, inserts and updates, by name
+	Operations []OperationSnapshot
 
-	AvgInsertLatency time.Duration
-	P95InsertLatency time.Duration
-	P99InsertLatency time.Duration
+	Stage StageSnapshot // Position in the load profile, zero when running closed-loop
 
-	AvgUpdateLatency time.Duration
-	P95UpdateLatency time.Duration
-	P99UpdateLatency time.Duration
+	Backpressure BackpressureSnapshot // Zero until the connection monitor reported
+
+	ActiveWorkers int // Workers currently issuing operations
 
 	ActiveConns    int32
 	MaxConns       int32
 	AvailableConns int32
+
+	Pools []PoolSnapshot // Activity of every client connection pool
+
+	Errors  []ErrorCount // Failed operations per kind, most frequent first
+	Retries []ErrorCount // Retried attempts per kind, most frequent first
+
+	ReplicaLags []ReplicaLag // Latest lag of every replica, empty when not measured
+
+	// Replica consistency checks, zero when the consistency mode is off
+	ConsistencyChecks   int64
+	StaleReads          int64
+	MonotonicViolations int64
 }
 
 // NewV2 creates a new MetricsV2 instance
 func NewV2() *MetricsV2 {
 	return &MetricsV2{
-		startTime:       time.Now(),
-		lastReportTime:  time.Now(),
-		readLatencies:   make([]time.Duration, 0, 10000),
-		insertLatencies: make([]time.Duration, 0, 10000),
-		updateLatencies: make([]time.Duration, 0, 10000),
+		startTime:      time.Now(),
+		lastReportTime: time.Now(),
+		readLatency:    NewHistogram(),
+		insertLatency:  NewHistogram(),
+		updateLatency:  NewHistogram(),
+		startDelay:     NewHistogram(),
+		splitBrain:     NewSplitBrainDetector(),
+		failover:       NewFailoverAnalyzer(),
+		replication:    NewReplicationLagTracker(),
+		consistency:    NewConsistencyTracker(),
+		pools:          NewPoolTracker(),
+		errors:         NewErrorTracker(),
+		retries:        NewErrorTracker(),
+		operations:     NewOperationTracker(),
+
+		latencyHistogram: newLatencyHistogram(),
 	}
 }
 
@@ -113,26 +209,16 @@ func NewV2() *MetricsV2 {
 func (m *MetricsV2) RecordRead(latency time.Duration, bytesRead int64) {
 	m.totalReads.Add(1)
 	m.totalBytes.Add(bytesRead)
-
-	m.latencyMutex.Lock()
-	m.readLatencies = append(m.readLatencies, latency)
-	if len(m.readLatencies) > 10000 {
-		m.readLatencies = m.readLatencies[len(m.readLatencies)-10000:]
-	}
-	m.latencyMutex.Unlock()
+	m.latencyHistogram.WithLabelValues("read").Observe(latency.Seconds())
+	m.readLatency.Record(latency)
 }
 
 // RecordInsert records a successful insert operation
 func (m *MetricsV2) RecordInsert(latency time.Duration, bytesWritten int64) {
 	m.totalInserts.Add(1)
 	m.totalBytes.Add(bytesWritten)
-
-	m.latencyMutex.Lock()
-	m.insertLatencies = append(m.insertLatencies, latency)
-	if len(m.insertLatencies) > 10000 {
-		m.insertLatencies = m.insertLatencies[len(m.insertLatencies)-10000:]
-	}
-	m.latencyMutex.Unlock()
+	m.latencyHistogram.WithLabelValues("insert").Observe(latency.Seconds())
+	m.insertLatency.Record(latency)
 }
 
 // RecordInsertedID records an inserted record ID for data loss tracking
@@ -153,22 +239,255 @@ func (m *MetricsV2) GetInsertedIDs() []int64 {
 	return ids
 }
 
+// RecordAck records which server acknowledged a write and when, by the server clock
+func (m *MetricsV2) RecordAck(server string, at time.Time) {
+	m.splitBrain.RecordAck(server, at)
+}
+
+// SplitBrain returns the detector tracking which servers acknowledged writes
+func (m *MetricsV2) SplitBrain() *SplitBrainDetector {
+	return m.splitBrain
+}
+
+// RecordWriteSuccess records a write issued at start and acknowledged by server at ackedAt
+func (m *MetricsV2) RecordWriteSuccess(start, ackedAt time.Time, server string) {
+	m.failover.RecordWriteSuccess(start, ackedAt, server)
+}
+
+// RecordWriteFailure records a write that failed at the given time
+func (m *MetricsV2) RecordWriteFailure(at time.Time) {
+	m.failover.RecordWriteFailure(at)
+}
+
+// Failover returns the analyzer tracking write outages
+func (m *MetricsV2) Failover() *FailoverAnalyzer {
+	return m.failover
+}
+
+// RecordReplicaLag records a replica apply lag measurement
+func (m *MetricsV2) RecordReplicaLag(sample ReplicaLag) {
+	m.replication.Record(sample)
+}
+
+// Replication returns the tracker holding every replica's lag series
+func (m *MetricsV2) Replication() *ReplicationLagTracker {
+	return m.replication
+}
+
+// RecordConsistencyCheck records a read-your-writes and monotonic-read check against a replica
+func (m *MetricsV2) RecordConsistencyCheck(replica string, stale bool, staleness time.Duration, violation bool) {
+	m.consistency.RecordCheck(replica, stale, staleness, violation)
+}
+
+// Consistency returns the tracker holding the replica consistency checks
+func (m *MetricsV2) Consistency() *ConsistencyTracker {
+	return m.consistency
+}
+
+// RecordPoolOp records an operation served by the named connection pool
+func (m *MetricsV2) RecordPoolOp(pool string, latency time.Duration, failed bool) {
+	m.pools.RecordOp(pool, latency, failed)
+}
+
+// UpdatePoolStats records the client-side connection state of the named pool
+func (m *MetricsV2) UpdatePoolStats(pool string, stats PoolStats) {
+	m.pools.UpdateStats(pool, stats)
+}
+
 // RecordUpdate records a successful update operation
 func (m *MetricsV2) RecordUpdate(latency time.Duration, bytesWritten int64) {
 	m.totalUpdates.Add(1)
 	m.totalBytes.Add(bytesWritten)
+	m.latencyHistogram.WithLabelValues("update").Observe(latency.Seconds())
+	m.updateLatency.Record(latency)
+}
+
+// RecordOperation records a successful operation by name. Reads, inserts
+// and updates are recorded as by RecordRead, RecordInsert and RecordUpdate;
+// every other operation is counted and timed on its own.
+func (m *MetricsV2) RecordOperation(name string, latency time.Duration, bytes int64) {
+	switch name {
+	case "read":
+		m.RecordRead(latency, bytes)
+	case "insert":
+		m.RecordInsert(latency, bytes)
+	case "update":
+		m.RecordUpdate(latency, bytes)
+	default:
+		m.totalBytes.Add(bytes)
+		m.latencyHistogram.WithLabelValues(name).Observe(latency.Seconds())
+		m.operations.Record(name, latency)
+	}
+}
+
+// RecordStartDelay records how long after its intended start an operation
+// began in fixed-rate mode
+func (m *MetricsV2) RecordStartDelay(delay time.Duration) {
+	m.startDelay.Record(delay)
+}
+
+// StageSnapshot is the position in the staged load profile
+type StageSnapshot struct {
+	Index       int // Zero-based; equal to Count once the profile has ended
+	Count       int
+	Description string
+	TargetRate  float64 // Operations per second the profile asks for right now
+}
+
+// String formats the stage for the periodic report
+func (s StageSnapshot) String() string {
+	if s.Index >= s.Count {
+		return fmt.Sprintf("profile finished (%d stages)", s.Count)
+	}
+	return fmt.Sprintf("%d/%d: %s (target now %.2f ops/s)", s.Index+1, s.Count, s.Description, s.TargetRate)
+}
+
+// UpdateStage records the current position in the load profile
+func (m *MetricsV2) UpdateStage(stage StageSnapshot) {
+	m.stage.Store(&stage)
+}
+
+// BackpressureSnapshot is the state of the free connection backpressure
+type BackpressureSnapshot struct {
+	Limit     int  // Current connection cap of the write pool; 0 while paused
+	Max       int  // Uncapped size of the write pool
+	Paused    bool // Workers are paused
+	Throttled int64
+	PausedFor time.Duration
+}
+
+// String formats the backpressure state for the periodic report
+func (s BackpressureSnapshot) String() string {
+	state := "inactive"
+	switch {
+	case s.Paused:
+		state = "workers paused"
+	case s.Limit < s.Max:
+		state = fmt.Sprintf("write pool limited to %d of %d connections", s.Limit, s.Max)
+	}
+	return fmt.Sprintf("%s (throttled %d times, paused for %v)", state, s.Throttled, s.PausedFor.Round(time.Second))
+}
+
+// UpdateBackpressure records the current state of the free connection backpressure
+func (m *MetricsV2) UpdateBackpressure(s BackpressureSnapshot) {
+	m.backpressure.Store(&s)
+}
+
+// UpdateActiveWorkers records how many workers are currently issuing operations
+func (m *MetricsV2) UpdateActiveWorkers(n int) {
+	m.activeWorkers.Store(int32(n))
+}
+
+// Progress is the cumulative activity of the run, for consumers that measure
+// their own intervals without disturbing the periodic report
+type Progress struct {
+	ReadLatency   HistogramSnapshot
+	InsertLatency HistogramSnapshot
+	UpdateLatency HistogramSnapshot
+	OtherLatency  map[string]HistogramSnapshot // Other operations, by name
+	Operations    int64
+	Errors        int64
+}
+
+// Progress returns the activity of the run so far
+func (m *MetricsV2) Progress() Progress {
+	p := Progress{
+		ReadLatency:   m.readLatency.Snapshot(),
+		InsertLatency: m.insertLatency.Snapshot(),
+		UpdateLatency: m.updateLatency.Snapshot(),
+		OtherLatency:  make(map[string]HistogramSnapshot),
+		Operations:    m.totalReads.Load() + m.totalInserts.Load() + m.totalUpdates.Load(),
+		Errors:        m.totalErrors.Load(),
+	}
+	for _, op := range m.operations.Current() {
+		p.OtherLatency[op.Name] = op.latency
+		p.Operations += op.Total
+	}
+	return p
+}
 
-	m.latencyMutex.Lock()
-	m.updateLatencies = append(m.updateLatencies, latency)
-	if len(m.updateLatencies) > 10000 {
-		m.updateLatencies = m.updateLatencies[len(m.updateLatencies)-10000:]
+// Since returns the activity between prev, an earlier Progress, and p
+func (p Progress) Since(prev Progress) Progress {
+	window := Progress{
+		ReadLatency:   p.ReadLatency.Sub(prev.ReadLatency),
+		InsertLatency: p.InsertLatency.Sub(prev.InsertLatency),
+		UpdateLatency: p.UpdateLatency.Sub(prev.UpdateLatency),
+		OtherLatency:  make(map[string]HistogramSnapshot, len(p.OtherLatency)),
+		Operations:    p.Operations - prev.Operations,
+		Errors:        p.Errors - prev.Errors,
 	}
-	m.latencyMutex.Unlock()
+	for name, latency := range p.OtherLatency {
+		window.OtherLatency[name] = latency.Sub(prev.OtherLatency[name])
+	}
+	return window
+}
+
+// WorstP99 returns the highest p99 among the operation types
+func (p Progress) WorstP99() time.Duration {
+	worst := max(p.ReadLatency.Percentile(99), p.InsertLatency.Percentile(99), p.UpdateLatency.Percentile(99))
+	for _, latency := range p.OtherLatency {
+		worst = max(worst, latency.Percentile(99))
+	}
+	return worst
 }
 
-// RecordError records an error
-func (m *MetricsV2) RecordError() {
+// ErrorPercent returns the share of failed operations
+func (p Progress) ErrorPercent() float64 {
+	return percentOf(p.Errors, p.Operations+p.Errors)
+}
+
+// RecordError records a failed operation of the given kind
+func (m *MetricsV2) RecordError(kind ErrorKind) {
 	m.totalErrors.Add(1)
+	m.errors.Record(kind)
+}
+
+// RecordRetry records a failed attempt of the given kind that is retried.
+// It is not counted as an error.
+func (m *MetricsV2) RecordRetry(kind ErrorKind) {
+	m.totalRetries.Add(1)
+	m.retries.Record(kind)
+}
+
+// RecordFirstAttemptSuccess records an operation that succeeded without a retry
+func (m *MetricsV2) RecordFirstAttemptSuccess() {
+	m.firstAttemptSuccesses.Add(1)
+}
+
+// RecordRetriedSuccess records an operation that succeeded after at least one retry
+func (m *MetricsV2) RecordRetriedSuccess() {
+	m.retriedSuccesses.Add(1)
+}
+
+// RecordRetriesExhausted records an operation that still failed on its last allowed attempt
+func (m *MetricsV2) RecordRetriesExhausted() {
+	m.retriesExhausted.Add(1)
+}
+
+// RecordInDoubt records a failed write whose commit outcome is unknown.
+// It is counted in addition to the error recorded for the same write.
+func (m *MetricsV2) RecordInDoubt() {
+	m.totalInDoubt.Add(1)
+}
+
+// DataLoss is the outcome of data loss verification
+type DataLoss struct {
+	Acknowledged int64 `json:"acknowledged"` // Acknowledged rows that were checked
+	Lost         int64 `json:"lost"`         // Acknowledged rows that were not found
+	Corrupted    int64 `json:"corrupted"`    // Rows whose payload no longer matches its checksum
+	LostUpdates  int64 `json:"lost_updates"` // Rows that lost an acknowledged update
+
+	DuplicateKeys int64 `json:"duplicate_keys"` // Client keys written to more than one row
+}
+
+// Percent returns the share of acknowledged rows that were lost
+func (d DataLoss) Percent() float64 {
+	return percentOf(d.Lost, d.Acknowledged)
+}
+
+// RecordDataLoss records the outcome of data loss verification
+func (m *MetricsV2) RecordDataLoss(loss DataLoss) {
+	m.dataLoss.Store(&loss)
 }
 
 // UpdateConnectionMetrics updates connection-related metrics
@@ -185,58 +504,93 @@ func (m *MetricsV2) GetSnapshot() MetricsSnapshotV2 {
 	intervalDuration := now.Sub(m.lastReportTime)
 
 	snapshot := MetricsSnapshotV2{
-		Duration:       duration,
-		TotalReads:     m.totalReads.Load(),
-		TotalInserts:   m.totalInserts.Load(),
-		TotalUpdates:   m.totalUpdates.Load(),
-		TotalErrors:    m.totalErrors.Load(),
-		TotalBytes:     m.totalBytes.Load(),
+		Duration:     duration,
+		TotalReads:   m.totalReads.Load(),
+		TotalInserts: m.totalInserts.Load(),
+		TotalUpdates: m.totalUpdates.Load(),
+		TotalErrors:  m.totalErrors.Load(),
+		TotalInDoubt: m.totalInDoubt.Load(),
+		TotalBytes:   m.totalBytes.Load(),
+
+		TotalRetries:          m.totalRetries.Load(),
+		FirstAttemptSuccesses: m.firstAttemptSuccesses.Load(),
+		RetriedSuccesses:      m.retriedSuccesses.Load(),
+		RetriesExhausted:      m.retriesExhausted.Load(),
+
+		ActiveWorkers:  int(m.activeWorkers.Load()),
 		ActiveConns:    m.activeConns.Load(),
 		MaxConns:       m.maxConns.Load(),
 		AvailableConns: m.availableConns.Load(),
+		ReplicaLags:    m.replication.Latest(),
 	}
 
-	snapshot.TotalOperations = snapshot.TotalReads + snapshot.TotalInserts + snapshot.TotalUpdates
+	snapshot.Operations = m.operations.Snapshot(intervalDuration)
+	var totalOther int64
+	for _, op := range snapshot.Operations {
+		totalOther += op.Total
+	}
+	snapshot.TotalOperations = snapshot.TotalReads + snapshot.TotalInserts + snapshot.TotalUpdates + totalOther
+	snapshot.Pools = m.pools.Snapshot(intervalDuration)
+	snapshot.Errors = m.errors.Snapshot()
+	snapshot.Retries = m.retries.Snapshot()
+	snapshot.ConsistencyChecks, snapshot.StaleReads, snapshot.MonotonicViolations = m.consistency.Totals()
+	if loss := m.dataLoss.Load(); loss != nil {
+		snapshot.TotalInsertedIDs = loss.Acknowledged
+		snapshot.LostRecords = loss.Lost
+		snapshot.DataLossPercent = loss.Percent()
+	}
 
 	// Calculate rates based on interval
 	if intervalDuration.Seconds() > 0 {
 		readsDiff := snapshot.TotalReads - m.lastReadCount
 		insertsDiff := snapshot.TotalInserts - m.lastInsertCount
 		updatesDiff := snapshot.TotalUpdates - m.lastUpdateCount
+		otherDiff := totalOther - m.lastOtherCount
 		errorsDiff := snapshot.TotalErrors - m.lastErrorCount
 		bytesDiff := snapshot.TotalBytes - m.lastBytesCount
 
 		snapshot.ReadsPerSec = float64(readsDiff) / intervalDuration.Seconds()
 		snapshot.InsertsPerSec = float64(insertsDiff) / intervalDuration.Seconds()
 		snapshot.UpdatesPerSec = float64(updatesDiff) / intervalDuration.Seconds()
-		snapshot.OpsPerSec = float64(readsDiff+insertsDiff+updatesDiff) / intervalDuration.Seconds()
+		snapshot.OpsPerSec = float64(readsDiff+insertsDiff+updatesDiff+otherDiff) / intervalDuration.Seconds()
 		snapshot.ErrorsPerSec = float64(errorsDiff) / intervalDuration.Seconds()
 		snapshot.BytesPerSec = float64(bytesDiff) / intervalDuration.Seconds()
 	}
 
-	// Calculate latency percentiles
-	m.latencyMutex.RLock()
-	if len(m.readLatencies) > 0 {
-		snapshot.AvgReadLatency = calculateAvg(m.readLatencies)
-		snapshot.P95ReadLatency = calculatePercentile(m.readLatencies, 95)
-		snapshot.P99ReadLatency = calculatePercentile(m.readLatencies, 99)
+	// Calculate latency percentiles for the whole run and the interval
+	reads := m.readLatency.Snapshot()
+	inserts := m.insertLatency.Snapshot()
+	updates := m.updateLatency.Snapshot()
+	snapshot.ReadLatency = reads.Stats()
+	snapshot.InsertLatency = inserts.Stats()
+	snapshot.UpdateLatency = updates.Stats()
+	intervalReads := reads.Sub(m.lastReadLatency)
+	intervalInserts := inserts.Sub(m.lastInsertLatency)
+	intervalUpdates := updates.Sub(m.lastUpdateLatency)
+	intervalAll := intervalReads.Merge(intervalInserts).Merge(intervalUpdates)
+	for _, op := range snapshot.Operations {
+		intervalAll = intervalAll.Merge(op.intervalLatency)
 	}
-	if len(m.insertLatencies) > 0 {
-		snapshot.AvgInsertLatency = calculateAvg(m.insertLatencies)
-		snapshot.P95InsertLatency = calculatePercentile(m.insertLatencies, 95)
-		snapshot.P99InsertLatency = calculatePercentile(m.insertLatencies, 99)
+	snapshot.IntervalLatency = intervalAll.Stats()
+	snapshot.IntervalReadLatency = intervalReads.Stats()
+	snapshot.IntervalInsertLatency = intervalInserts.Stats()
+	snapshot.IntervalUpdateLatency = intervalUpdates.Stats()
+	m.lastReadLatency = reads
+	m.lastInsertLatency = inserts
+	m.lastUpdateLatency = updates
+	snapshot.StartDelay = m.startDelay.Snapshot().Stats()
+	if stage := m.stage.Load(); stage != nil {
+		snapshot.Stage = *stage
 	}
-	if len(m.updateLatencies) > 0 {
-		snapshot.AvgUpdateLatency = calculateAvg(m.updateLatencies)
-		snapshot.P95UpdateLatency = calculatePercentile(m.updateLatencies, 95)
-		snapshot.P99UpdateLatency = calculatePercentile(m.updateLatencies, 99)
+	if backpressure := m.backpressure.Load(); backpressure != nil {
+		snapshot.Backpressure = *backpressure
 	}
-	m.latencyMutex.RUnlock()
 
 	// Update last counts for rate calculation
 	m.lastReadCount = snapshot.TotalReads
 	m.lastInsertCount = snapshot.TotalInserts
 	m.lastUpdateCount = snapshot.TotalUpdates
+	m.lastOtherCount = totalOther
 	m.lastErrorCount = snapshot.TotalErrors
 	m.lastBytesCount = snapshot.TotalBytes
 	m.lastReportTime = now
@@ -250,9 +604,14 @@ func (s *MetricsSnapshotV2) Print() {
 	fmt.Printf("Test Duration: %v\n", s.Duration.Round(time.Second))
 	fmt.Println("-----------------------------------------------------------------")
 	fmt.Println("Cumulative Statistics:")
-	fmt.Printf("  Total Operations: %d (Reads: %d, Inserts: %d, Updates: %d)\n",
-		s.TotalOperations, s.TotalReads, s.TotalInserts, s.TotalUpdates)
-	fmt.Printf("  Total Errors: %d\n", s.TotalErrors)
+	fmt.Printf("  Total Operations: %d (Reads: %d, Inserts: %d, Updates: %d%s)\n",
+		s.TotalOperations, s.TotalReads, s.TotalInserts, s.TotalUpdates,
+		s.otherOperations(func(op OperationSnapshot) string { return fmt.Sprintf("%d", op.Total) }))
+	fmt.Printf("  Total Errors: %d (commit outcome unknown: %d)\n", s.TotalErrors, s.TotalInDoubt)
+	if s.TotalRetries > 0 {
+		fmt.Printf("  Retries: %d (first-attempt successes: %d, succeeded after retrying: %d, gave up: %d)\n",
+			s.TotalRetries, s.FirstAttemptSuccesses, s.RetriedSuccesses, s.RetriesExhausted)
+	}
 	fmt.Printf("  Total Data Transferred: %.2f MB\n", float64(s.TotalBytes)/(1024*1024))
 	if s.TotalInsertedIDs > 0 {
 		fmt.Printf("  Data Loss: %d records lost out of %d inserted (%.2f%%)\n",
@@ -260,33 +619,91 @@ func (s *MetricsSnapshotV2) Print() {
 	}
 	fmt.Println("-----------------------------------------------------------------")
 	fmt.Println("Current Throughput (interval):")
-	fmt.Printf("  Operations/sec: %.2f (Reads: %.2f/s, Inserts: %.2f/s, Updates: %.2f/s)\n",
-		s.OpsPerSec, s.ReadsPerSec, s.InsertsPerSec, s.UpdatesPerSec)
+	fmt.Printf("  Operations/sec: %.2f (Reads: %.2f/s, Inserts: %.2f/s, Updates: %.2f/s%s)\n",
+		s.OpsPerSec, s.ReadsPerSec, s.InsertsPerSec, s.UpdatesPerSec,
+		s.otherOperations(func(op OperationSnapshot) string { return fmt.Sprintf("%.2f/s", op.PerSec) }))
 	fmt.Printf("  Throughput: %.2f MB/s\n", s.BytesPerSec/(1024*1024))
 	fmt.Printf("  Errors/sec: %.2f\n", s.ErrorsPerSec)
+	fmt.Printf("  Active Workers: %d\n", s.ActiveWorkers)
+	if s.Stage.Count > 0 {
+		fmt.Printf("  Stage %s\n", s.Stage)
+	}
+	if len(s.Errors) > 0 {
+		fmt.Println("-----------------------------------------------------------------")
+		fmt.Println("Errors (operation/phase, SQLSTATE):")
+		printErrorCounts(s.Errors)
+	}
+	if len(s.Retries) > 0 {
+		fmt.Println("-----------------------------------------------------------------")
+		fmt.Println("Retried Attempts (operation/phase, SQLSTATE):")
+		printErrorCounts(s.Retries)
+	}
 	fmt.Println("-----------------------------------------------------------------")
-	fmt.Println("Latency Statistics:")
-	if s.AvgReadLatency > 0 {
-		fmt.Printf("  Reads   - Avg: %v, P95: %v, P99: %v\n",
-			s.AvgReadLatency.Round(time.Microsecond),
-			s.P95ReadLatency.Round(time.Microsecond),
-			s.P99ReadLatency.Round(time.Microsecond))
-	}
-	if s.AvgInsertLatency > 0 {
-		fmt.Printf("  Inserts - Avg: %v, P95: %v, P99: %v\n",
-			s.AvgInsertLatency.Round(time.Microsecond),
-			s.P95InsertLatency.Round(time.Microsecond),
-			s.P99InsertLatency.Round(time.Microsecond))
-	}
-	if s.AvgUpdateLatency > 0 {
-		fmt.Printf("  Updates - Avg: %v, P95: %v, P99: %v\n",
-			s.AvgUpdateLatency.Round(time.Microsecond),
-			s.P95UpdateLatency.Round(time.Microsecond),
-			s.P99UpdateLatency.Round(time.Microsecond))
+	fmt.Println("Latency Statistics (whole run):")
+	if s.ReadLatency.Count > 0 {
+		fmt.Printf("  Reads   - %s\n", s.ReadLatency)
+	}
+	if s.InsertLatency.Count > 0 {
+		fmt.Printf("  Inserts - %s\n", s.InsertLatency)
+	}
+	if s.UpdateLatency.Count > 0 {
+		fmt.Printf("  Updates - %s\n", s.UpdateLatency)
+	}
+	for _, op := range s.Operations {
+		if op.Latency.Count > 0 {
+			fmt.Printf("  %-7s - %s\n", op.Name, op.Latency)
+		}
+	}
+	fmt.Println("Latency Statistics (interval):")
+	if s.IntervalReadLatency.Count > 0 {
+		fmt.Printf("  Reads   - %s\n", s.IntervalReadLatency)
+	}
+	if s.IntervalInsertLatency.Count > 0 {
+		fmt.Printf("  Inserts - %s\n", s.IntervalInsertLatency)
+	}
+	if s.IntervalUpdateLatency.Count > 0 {
+		fmt.Printf("  Updates - %s\n", s.IntervalUpdateLatency)
+	}
+	for _, op := range s.Operations {
+		if op.IntervalLatency.Count > 0 {
+			fmt.Printf("  %-7s - %s\n", op.Name, op.IntervalLatency)
+		}
+	}
+	if s.StartDelay.Count > 0 {
+		fmt.Printf("Behind Schedule (whole run):\n  %s\n", s.StartDelay)
 	}
 	fmt.Println("-----------------------------------------------------------------")
 	fmt.Println("Connection Pool:")
 	fmt.Printf("  Active: %d, Max: %d, Available: %d\n",
 		s.ActiveConns, s.MaxConns, s.AvailableConns)
+	for _, pool := range s.Pools {
+		fmt.Printf("  %s\n", pool)
+	}
+	if s.Backpressure.Throttled > 0 {
+		fmt.Printf("  Backpressure: %s\n", s.Backpressure)
+	}
+	if s.ConsistencyChecks > 0 {
+		fmt.Println("-----------------------------------------------------------------")
+		fmt.Println("Replica Consistency:")
+		fmt.Printf("  Checks: %d, Stale Reads: %d (%.2f%%), Monotonic-Read Violations: %d\n",
+			s.ConsistencyChecks, s.StaleReads, percentOf(s.StaleReads, s.ConsistencyChecks), s.MonotonicViolations)
+	}
+	if len(s.ReplicaLags) > 0 {
+		fmt.Println("-----------------------------------------------------------------")
+		fmt.Println("Replication Lag:")
+		for _, lag := range s.ReplicaLags {
+			fmt.Printf("  %s\n", lag)
+		}
+	}
 	fmt.Println("=================================================================")
 }
+
+// otherOperations formats the operations other than reads, inserts and
+// updates for a list following the built-in ones, e.g. ", delete: 12"
+func (s *MetricsSnapshotV2) otherOperations(value func(OperationSnapshot) string) string {
+	var b strings.Builder
+	for _, op := range s.Operations {
+		fmt.Fprintf(&b, ", %s: %s", op.Name, value(op))
+	}
+	return b.String()
+}
@@ -0,0 +1,112 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// OperationTracker counts and times successful operations by name, for
+// operations other than the built-in reads, inserts and updates
+type OperationTracker struct {
+	mu  sync.Mutex
+	ops map[string]*operationCounters
+}
+
+// operationCounters accumulates the activity of one named operation
+type operationCounters struct {
+	count       int64
+	latency     *Histogram
+	lastCount   int64             // For the per-interval rate
+	lastLatency HistogramSnapshot // For per-interval percentiles
+}
+
+// OperationSnapshot is the activity of one named operation at a point in time
+type OperationSnapshot struct {
+	Name            string
+	Total           int64
+	PerSec          float64
+	Latency         LatencyStats // Whole run
+	IntervalLatency LatencyStats // Since the previous snapshot
+
+	latency         HistogramSnapshot
+	intervalLatency HistogramSnapshot
+}
+
+// NewOperationTracker creates an empty tracker
+func NewOperationTracker() *OperationTracker {
+	return &OperationTracker{
+		ops: make(map[string]*operationCounters),
+	}
+}
+
+// Record records a successful operation
+func (t *OperationTracker) Record(name string, latency time.Duration) {
+	t.mu.Lock()
+	op, ok := t.ops[name]
+	if !ok {
+		op = &operationCounters{latency: NewHistogram()}
+		t.ops[name] = op
+	}
+	op.count++
+	t.mu.Unlock()
+
+	op.latency.Record(latency)
+}
+
+// Snapshot returns every operation's activity, sorted by name, and starts a
+// new interval. interval is the time since the previous snapshot.
+func (t *OperationTracker) Snapshot(interval time.Duration) []OperationSnapshot {
+	return t.snapshot(interval, true)
+}
+
+// Current returns every operation's totals, sorted by name, without a rate
+// and without starting a new interval
+func (t *OperationTracker) Current() []OperationSnapshot {
+	return t.snapshot(0, false)
+}
+
+// snapshot collects every operation's activity; advance starts a new interval
+func (t *OperationTracker) snapshot(interval time.Duration, advance bool) []OperationSnapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snapshots := make([]OperationSnapshot, 0, len(t.ops))
+	for name, op := range t.ops {
+		latency := op.latency.Snapshot()
+		s := OperationSnapshot{
+			Name:            name,
+			Total:           op.count,
+			latency:         latency,
+			intervalLatency: latency.Sub(op.lastLatency),
+		}
+		s.Latency = s.latency.Stats()
+		s.IntervalLatency = s.intervalLatency.Stats()
+		if interval > 0 {
+			s.PerSec = float64(op.count-op.lastCount) / interval.Seconds()
+		}
+		if advance {
+			op.lastCount = op.count
+			op.lastLatency = latency
+		}
+		snapshots = append(snapshots, s)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Name < snapshots[j].Name })
+	return snapshots
+}
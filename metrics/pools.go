@@ -0,0 +1,142 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PoolTracker keeps operation counts and client-side connection stats per connection pool
+type PoolTracker struct {
+	mu    sync.Mutex
+	pools map[string]*poolCounters
+}
+
+// poolCounters accumulates the activity of one pool
+type poolCounters struct {
+	ops          int64
+	errors       int64
+	totalLatency time.Duration
+	lastOps      int64 // For the per-interval rate
+	stats        PoolStats
+}
+
+// PoolStats is the client-side connection state of a pool, as in sql.DBStats
+type PoolStats struct {
+	Open      int
+	InUse     int
+	Idle      int
+	WaitCount int64
+}
+
+// PoolSnapshot is the activity of one pool at a point in time
+type PoolSnapshot struct {
+	Name        string
+	TotalOps    int64
+	TotalErrors int64
+	OpsPerSec   float64
+	AvgLatency  time.Duration
+	PoolStats
+}
+
+// NewPoolTracker creates an empty tracker
+func NewPoolTracker() *PoolTracker {
+	return &PoolTracker{
+		pools: make(map[string]*poolCounters),
+	}
+}
+
+// pool returns the counters of a pool, creating them if needed. The caller holds mu.
+func (t *PoolTracker) pool(name string) *poolCounters {
+	p, ok := t.pools[name]
+	if !ok {
+		p = &poolCounters{}
+		t.pools[name] = p
+	}
+	return p
+}
+
+// RecordOp records an operation served by the named pool
+func (t *PoolTracker) RecordOp(name string, latency time.Duration, failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p := t.pool(name)
+	if failed {
+		p.errors++
+		return
+	}
+	p.ops++
+	p.totalLatency += latency
+}
+
+// UpdateStats records the current connection state of the named pool
+func (t *PoolTracker) UpdateStats(name string, stats PoolStats) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pool(name).stats = stats
+}
+
+// Snapshot returns every pool's activity, sorted by name. interval is the
+// time since the previous snapshot and is used for the per-second rate.
+func (t *PoolTracker) Snapshot(interval time.Duration) []PoolSnapshot {
+	return t.snapshot(interval, true)
+}
+
+// Current returns every pool's totals and connection state, sorted by name,
+// without a rate and without starting a new interval
+func (t *PoolTracker) Current() []PoolSnapshot {
+	return t.snapshot(0, false)
+}
+
+// snapshot collects every pool's activity; advance starts a new rate interval
+func (t *PoolTracker) snapshot(interval time.Duration, advance bool) []PoolSnapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snapshots := make([]PoolSnapshot, 0, len(t.pools))
+	for name, p := range t.pools {
+		s := PoolSnapshot{
+			Name:        name,
+			TotalOps:    p.ops,
+			TotalErrors: p.errors,
+			PoolStats:   p.stats,
+		}
+		if p.ops > 0 {
+			s.AvgLatency = p.totalLatency / time.Duration(p.ops)
+		}
+		if interval > 0 {
+			s.OpsPerSec = float64(p.ops-p.lastOps) / interval.Seconds()
+		}
+		if advance {
+			p.lastOps = p.ops
+		}
+		snapshots = append(snapshots, s)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Name < snapshots[j].Name })
+	return snapshots
+}
+
+// String formats the pool's activity for the periodic report
+func (s PoolSnapshot) String() string {
+	return fmt.Sprintf("%s: %d ops (%.2f/s), %d errors, avg %v | open %d, in use %d, idle %d, waited %d",
+		s.Name, s.TotalOps, s.OpsPerSec, s.TotalErrors, s.AvgLatency.Round(time.Microsecond),
+		s.Open, s.InUse, s.Idle, s.WaitCount)
+}
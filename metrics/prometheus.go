@@ -0,0 +1,230 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every exported metric name
+const namespace = "loadclient"
+
+// latencyBuckets are the operation latency histogram buckets in seconds, from 0.5ms to 30s
+var latencyBuckets = []float64{
+	0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30,
+}
+
+// newLatencyHistogram creates the per-operation latency histogram observed by MetricsV2
+func newLatencyHistogram() *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "operation_duration_seconds",
+		Help:      "Latency of successful operations.",
+		Buckets:   latencyBuckets,
+	}, []string{"op"})
+}
+
+var (
+	operationsDesc = prometheus.NewDesc(namespace+"_operations_total",
+		"Successful operations.", []string{"op"}, nil)
+	errorsDesc = prometheus.NewDesc(namespace+"_errors_total",
+		"Failed operations by operation, phase and SQLSTATE.", []string{"op", "phase", "code"}, nil)
+	retriesDesc = prometheus.NewDesc(namespace+"_retries_total",
+		"Failed attempts that were retried, by operation, phase and SQLSTATE.", []string{"op", "phase", "code"}, nil)
+	firstAttemptSuccessesDesc = prometheus.NewDesc(namespace+"_first_attempt_successes_total",
+		"Operations that succeeded without a retry.", nil, nil)
+	retriedSuccessesDesc = prometheus.NewDesc(namespace+"_retried_successes_total",
+		"Operations that succeeded after at least one retry.", nil, nil)
+	retriesExhaustedDesc = prometheus.NewDesc(namespace+"_retries_exhausted_total",
+		"Operations that still failed on their last allowed attempt.", nil, nil)
+	inDoubtDesc = prometheus.NewDesc(namespace+"_in_doubt_writes_total",
+		"Failed writes whose commit outcome is unknown.", nil, nil)
+	bytesDesc = prometheus.NewDesc(namespace+"_bytes_total",
+		"Approximate bytes read and written.", nil, nil)
+	connectionsDesc = prometheus.NewDesc(namespace+"_db_connections",
+		"Server connections as last seen by the connection monitor.", []string{"state"}, nil)
+	poolOperationsDesc = prometheus.NewDesc(namespace+"_pool_operations_total",
+		"Successful operations per client connection pool.", []string{"pool"}, nil)
+	poolErrorsDesc = prometheus.NewDesc(namespace+"_pool_errors_total",
+		"Failed operations per client connection pool.", []string{"pool"}, nil)
+	poolConnectionsDesc = prometheus.NewDesc(namespace+"_pool_connections",
+		"Client-side connections per pool.", []string{"pool", "state"}, nil)
+	replicaLagDesc = prometheus.NewDesc(namespace+"_replica_lag_seconds",
+		"Latest heartbeat apply lag of a replica.", []string{"replica"}, nil)
+	replicaLagBytesDesc = prometheus.NewDesc(namespace+"_replica_lag_bytes",
+		"Latest WAL replay distance of a replica behind the primary.", []string{"replica"}, nil)
+	activeWorkersDesc = prometheus.NewDesc(namespace+"_active_workers",
+		"Workers currently issuing operations.", nil, nil)
+	connLimitDesc = prometheus.NewDesc(namespace+"_write_pool_limit",
+		"Connection cap of the write pool set by backpressure; 0 while workers are paused.", nil, nil)
+	pausedSecondsDesc = prometheus.NewDesc(namespace+"_paused_seconds_total",
+		"Time workers were paused by backpressure.", nil, nil)
+	targetRateDesc = prometheus.NewDesc(namespace+"_target_rate",
+		"Operations per second the load profile asks for.", nil, nil)
+	stageDesc = prometheus.NewDesc(namespace+"_stage",
+		"One-based index of the current load profile stage.", nil, nil)
+	acknowledgedRowsDesc = prometheus.NewDesc(namespace+"_acknowledged_rows",
+		"Acknowledged rows checked by data loss verification.", nil, nil)
+	lostRowsDesc = prometheus.NewDesc(namespace+"_lost_rows",
+		"Acknowledged rows not found by data loss verification.", nil, nil)
+	dataLossRatioDesc = prometheus.NewDesc(namespace+"_data_loss_ratio",
+		"Share of acknowledged rows not found by data loss verification.", nil, nil)
+	corruptedRowsDesc = prometheus.NewDesc(namespace+"_corrupted_rows",
+		"Rows whose payload no longer matches its checksum.", nil, nil)
+	lostUpdatesDesc = prometheus.NewDesc(namespace+"_lost_updates",
+		"Rows that lost an acknowledged update.", nil, nil)
+)
+
+// collector exposes a MetricsV2 to Prometheus. Counters are read from the
+// same totals the periodic report uses, so both always agree.
+type collector struct {
+	m *MetricsV2
+}
+
+// Describe implements prometheus.Collector
+func (c collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		operationsDesc, errorsDesc, retriesDesc, firstAttemptSuccessesDesc, retriedSuccessesDesc, retriesExhaustedDesc, inDoubtDesc, bytesDesc, connectionsDesc,
+		poolOperationsDesc, poolErrorsDesc, poolConnectionsDesc,
+		replicaLagDesc, replicaLagBytesDesc, activeWorkersDesc, connLimitDesc, pausedSecondsDesc, targetRateDesc, stageDesc,
+		acknowledgedRowsDesc, lostRowsDesc, dataLossRatioDesc, corruptedRowsDesc, lostUpdatesDesc,
+	} {
+		ch <- d
+	}
+	c.m.latencyHistogram.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (c collector) Collect(ch chan<- prometheus.Metric) {
+	m := c.m
+
+	ch <- prometheus.MustNewConstMetric(operationsDesc, prometheus.CounterValue, float64(m.totalReads.Load()), "read")
+	ch <- prometheus.MustNewConstMetric(operationsDesc, prometheus.CounterValue, float64(m.totalInserts.Load()), "insert")
+	ch <- prometheus.MustNewConstMetric(operationsDesc, prometheus.CounterValue, float64(m.totalUpdates.Load()), "update")
+	for _, op := range m.operations.Current() {
+		ch <- prometheus.MustNewConstMetric(operationsDesc, prometheus.CounterValue, float64(op.Total), op.Name)
+	}
+	collectErrorCounts(ch, errorsDesc, m.errors.Current())
+	collectErrorCounts(ch, retriesDesc, m.retries.Current())
+	ch <- prometheus.MustNewConstMetric(firstAttemptSuccessesDesc, prometheus.CounterValue, float64(m.firstAttemptSuccesses.Load()))
+	ch <- prometheus.MustNewConstMetric(retriedSuccessesDesc, prometheus.CounterValue, float64(m.retriedSuccesses.Load()))
+	ch <- prometheus.MustNewConstMetric(retriesExhaustedDesc, prometheus.CounterValue, float64(m.retriesExhausted.Load()))
+	ch <- prometheus.MustNewConstMetric(inDoubtDesc, prometheus.CounterValue, float64(m.totalInDoubt.Load()))
+	ch <- prometheus.MustNewConstMetric(bytesDesc, prometheus.CounterValue, float64(m.totalBytes.Load()))
+	m.latencyHistogram.Collect(ch)
+
+	ch <- prometheus.MustNewConstMetric(connectionsDesc, prometheus.GaugeValue, float64(m.activeConns.Load()), "active")
+	ch <- prometheus.MustNewConstMetric(connectionsDesc, prometheus.GaugeValue, float64(m.maxConns.Load()), "max")
+	ch <- prometheus.MustNewConstMetric(connectionsDesc, prometheus.GaugeValue, float64(m.availableConns.Load()), "available")
+
+	for _, p := range m.pools.Current() {
+		ch <- prometheus.MustNewConstMetric(poolOperationsDesc, prometheus.CounterValue, float64(p.TotalOps), p.Name)
+		ch <- prometheus.MustNewConstMetric(poolErrorsDesc, prometheus.CounterValue, float64(p.TotalErrors), p.Name)
+		ch <- prometheus.MustNewConstMetric(poolConnectionsDesc, prometheus.GaugeValue, float64(p.Open), p.Name, "open")
+		ch <- prometheus.MustNewConstMetric(poolConnectionsDesc, prometheus.GaugeValue, float64(p.InUse), p.Name, "in_use")
+		ch <- prometheus.MustNewConstMetric(poolConnectionsDesc, prometheus.GaugeValue, float64(p.Idle), p.Name, "idle")
+	}
+
+	for _, l := range m.replication.Latest() {
+		if l.Err != "" {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(replicaLagDesc, prometheus.GaugeValue, l.Lag.Seconds(), l.Replica)
+		if l.Bytes >= 0 {
+			ch <- prometheus.MustNewConstMetric(replicaLagBytesDesc, prometheus.GaugeValue, float64(l.Bytes), l.Replica)
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(activeWorkersDesc, prometheus.GaugeValue, float64(m.activeWorkers.Load()))
+	if b := m.backpressure.Load(); b != nil {
+		ch <- prometheus.MustNewConstMetric(connLimitDesc, prometheus.GaugeValue, float64(b.Limit))
+		ch <- prometheus.MustNewConstMetric(pausedSecondsDesc, prometheus.CounterValue, b.PausedFor.Seconds())
+	}
+
+	if stage := m.stage.Load(); stage != nil {
+		ch <- prometheus.MustNewConstMetric(targetRateDesc, prometheus.GaugeValue, stage.TargetRate)
+		ch <- prometheus.MustNewConstMetric(stageDesc, prometheus.GaugeValue, float64(stage.Index+1))
+	}
+
+	// Data loss gauges only appear once verification has run
+	if loss := m.dataLoss.Load(); loss != nil {
+		ch <- prometheus.MustNewConstMetric(acknowledgedRowsDesc, prometheus.GaugeValue, float64(loss.Acknowledged))
+		ch <- prometheus.MustNewConstMetric(lostRowsDesc, prometheus.GaugeValue, float64(loss.Lost))
+		ch <- prometheus.MustNewConstMetric(dataLossRatioDesc, prometheus.GaugeValue, loss.Percent()/100)
+		ch <- prometheus.MustNewConstMetric(corruptedRowsDesc, prometheus.GaugeValue, float64(loss.Corrupted))
+		ch <- prometheus.MustNewConstMetric(lostUpdatesDesc, prometheus.GaugeValue, float64(loss.LostUpdates))
+	}
+}
+
+// collectErrorCounts emits one counter per operation, phase and code. Kinds
+// differing only in their name, such as the messages of "network" or
+// "client" errors, are added up, since a series may only be emitted once and
+// free-form names would make the label set unbounded.
+func collectErrorCounts(ch chan<- prometheus.Metric, desc *prometheus.Desc, counts []ErrorCount) {
+	type series struct{ op, phase, code string }
+	totals := make(map[series]int64)
+	var order []series
+	for _, c := range counts {
+		key := series{c.Op, c.Phase, c.Code}
+		if _, ok := totals[key]; !ok {
+			order = append(order, key)
+		}
+		totals[key] += c.Total
+	}
+	for _, key := range order {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(totals[key]), key.op, key.phase, key.code)
+	}
+}
+
+// Serve exposes the metrics in the Prometheus text format on addr at
+// /metrics until ctx is done
+func (m *MetricsV2) Serve(ctx context.Context, addr string) error {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collector{m: m},
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("metrics server failed: %w", err)
+	}
+	return nil
+}
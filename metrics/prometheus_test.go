@@ -0,0 +1,71 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCollectAddsUpKindsDifferingByName(t *testing.T) {
+	m := NewV2()
+	for _, name := range []string{"connection refused", "bad connection", "connection refused"} {
+		kind := ErrorKind{Op: "insert", Phase: PhaseExecute, Code: "network", Name: name}
+		m.RecordError(kind)
+		m.RecordRetry(kind)
+	}
+	m.RecordError(ErrorKind{Op: "insert", Phase: PhaseExecute, Code: "client", Name: "unexpected EOF"})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector{m: m})
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+
+	want := map[string]map[string]float64{
+		namespace + "_errors_total":  {"network": 3, "client": 1},
+		namespace + "_retries_total": {"network": 3},
+	}
+	for _, family := range families {
+		codes, ok := want[family.GetName()]
+		if !ok {
+			continue
+		}
+		got := make(map[string]float64)
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "code" {
+					got[label.GetValue()] = metric.GetCounter().GetValue()
+				}
+			}
+		}
+		for code, total := range codes {
+			if got[code] != total {
+				t.Errorf("%s{code=%q} = %v, want %v", family.GetName(), code, got[code], total)
+			}
+		}
+		if len(got) != len(codes) {
+			t.Errorf("%s has series %v, want %v", family.GetName(), got, codes)
+		}
+		delete(want, family.GetName())
+	}
+	for name := range want {
+		t.Errorf("%s missing", name)
+	}
+}
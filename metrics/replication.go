@@ -0,0 +1,171 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ReplicationLagTracker keeps the apply lag time series of every replica
+type ReplicationLagTracker struct {
+	mu       sync.Mutex
+	replicas map[string][]ReplicaLag
+}
+
+// ReplicaLag is one lag measurement of a replica
+type ReplicaLag struct {
+	Replica string
+	At      time.Time
+	Lag     time.Duration // Age of the newest heartbeat the replica has applied
+	Bytes   int64         // WAL bytes between the primary and the replica's replay position; -1 if unknown
+	Err     string        // Set when the replica could not be measured
+}
+
+// ReplicaLagSummary aggregates the lag series of one replica
+type ReplicaLagSummary struct {
+	Replica  string
+	Samples  int
+	Failures int
+	AvgLag   time.Duration
+	MaxLag   time.Duration
+	MaxLagAt time.Time
+	MaxBytes int64
+}
+
+// NewReplicationLagTracker creates an empty tracker
+func NewReplicationLagTracker() *ReplicationLagTracker {
+	return &ReplicationLagTracker{
+		replicas: make(map[string][]ReplicaLag),
+	}
+}
+
+// Record appends a measurement to the replica's series
+func (t *ReplicationLagTracker) Record(sample ReplicaLag) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.replicas[sample.Replica] = append(t.replicas[sample.Replica], sample)
+}
+
+// Latest returns the newest measurement of every replica, sorted by replica
+func (t *ReplicationLagTracker) Latest() []ReplicaLag {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	latest := make([]ReplicaLag, 0, len(t.replicas))
+	for _, series := range t.replicas {
+		if len(series) > 0 {
+			latest = append(latest, series[len(series)-1])
+		}
+	}
+	sort.Slice(latest, func(i, j int) bool { return latest[i].Replica < latest[j].Replica })
+	return latest
+}
+
+// Series returns a copy of the full lag series of a replica
+func (t *ReplicationLagTracker) Series(replica string) []ReplicaLag {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	series := make([]ReplicaLag, len(t.replicas[replica]))
+	copy(series, t.replicas[replica])
+	return series
+}
+
+// Summaries aggregates the series of every replica, sorted by replica
+func (t *ReplicationLagTracker) Summaries() []ReplicaLagSummary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	summaries := make([]ReplicaLagSummary, 0, len(t.replicas))
+	for replica, series := range t.replicas {
+		s := ReplicaLagSummary{Replica: replica, MaxBytes: -1}
+		var total time.Duration
+		for _, sample := range series {
+			if sample.Err != "" {
+				s.Failures++
+				continue
+			}
+			s.Samples++
+			total += sample.Lag
+			if sample.Lag >= s.MaxLag {
+				s.MaxLag = sample.Lag
+				s.MaxLagAt = sample.At
+			}
+			if sample.Bytes > s.MaxBytes {
+				s.MaxBytes = sample.Bytes
+			}
+		}
+		if s.Samples > 0 {
+			s.AvgLag = total / time.Duration(s.Samples)
+		}
+		summaries = append(summaries, s)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Replica < summaries[j].Replica })
+	return summaries
+}
+
+// String formats the measurement for the periodic report
+func (l ReplicaLag) String() string {
+	if l.Err != "" {
+		return fmt.Sprintf("%s: unavailable (%s)", l.Replica, l.Err)
+	}
+	if l.Bytes < 0 {
+		return fmt.Sprintf("%s: %v", l.Replica, l.Lag.Round(time.Millisecond))
+	}
+	return fmt.Sprintf("%s: %v, %s behind", l.Replica, l.Lag.Round(time.Millisecond), formatBytes(l.Bytes))
+}
+
+// Print prints the lag summary of every replica
+func (t *ReplicationLagTracker) Print() {
+	summaries := t.Summaries()
+
+	fmt.Println("=================================================================")
+	fmt.Println("Replication Lag Report:")
+	fmt.Println("-----------------------------------------------------------------")
+	if len(summaries) == 0 {
+		fmt.Println("  No replicas measured")
+	}
+	for _, s := range summaries {
+		fmt.Printf("  %s: %d samples, %d failed\n", s.Replica, s.Samples, s.Failures)
+		if s.Samples == 0 {
+			continue
+		}
+		fmt.Printf("    Avg lag: %v, Max lag: %v at %s\n",
+			s.AvgLag.Round(time.Millisecond), s.MaxLag.Round(time.Millisecond), s.MaxLagAt.Format(time.RFC3339))
+		if s.MaxBytes >= 0 {
+			fmt.Printf("    Max WAL behind: %s\n", formatBytes(s.MaxBytes))
+		}
+	}
+	fmt.Println("=================================================================")
+}
+
+// formatBytes formats a byte count with a binary unit
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1024*1024*1024:
+		return fmt.Sprintf("%.2f GB", float64(n)/(1024*1024*1024))
+	case n >= 1024*1024:
+		return fmt.Sprintf("%.2f MB", float64(n)/(1024*1024))
+	case n >= 1024:
+		return fmt.Sprintf("%.2f KB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
@@ -0,0 +1,123 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"time"
+)
+
+// minAchievedRatio is the share of the target rate a step must complete to be
+// sustainable; below it the client or the database could not keep up
+const minAchievedRatio = 0.9
+
+// SaturationSLO is what a step of the saturation search must meet
+type SaturationSLO struct {
+	P99          time.Duration // Highest acceptable p99 of every operation type
+	ErrorPercent float64       // Highest acceptable share of failed operations
+}
+
+// SaturationStep is the measured outcome of holding one target rate
+type SaturationStep struct {
+	TargetRate   int
+	Achieved     float64       // Successful operations per second
+	Latency      LatencyStats  // All operation types together
+	WorstP99     time.Duration // Highest p99 among the operation types
+	ErrorPercent float64
+	Sustainable  bool
+	Reason       string // Why the step is not sustainable
+}
+
+// SaturationReport is the throughput/latency curve of a saturation search
+type SaturationReport struct {
+	SLO   SaturationSLO
+	Steps []SaturationStep
+}
+
+// NewSaturationStep builds the step at targetRate from the snapshot taken at
+// its end, which covers exactly the step's interval
+func NewSaturationStep(targetRate int, s MetricsSnapshotV2) SaturationStep {
+	step := SaturationStep{
+		TargetRate: targetRate,
+		Achieved:   s.OpsPerSec,
+		Latency:    s.IntervalLatency,
+		WorstP99:   max(s.IntervalReadLatency.P99, s.IntervalInsertLatency.P99, s.IntervalUpdateLatency.P99),
+	}
+	for _, op := range s.Operations {
+		step.WorstP99 = max(step.WorstP99, op.IntervalLatency.P99)
+	}
+	if attempted := s.OpsPerSec + s.ErrorsPerSec; attempted > 0 {
+		step.ErrorPercent = s.ErrorsPerSec * 100 / attempted
+	}
+	return step
+}
+
+// Evaluate checks the step against the SLO and records the outcome in it
+func (slo SaturationSLO) Evaluate(step *SaturationStep) {
+	switch {
+	case step.WorstP99 > slo.P99:
+		step.Reason = fmt.Sprintf("p99 %v above %v", step.WorstP99.Round(time.Microsecond), slo.P99)
+	case step.ErrorPercent > slo.ErrorPercent:
+		step.Reason = fmt.Sprintf("error rate %.2f%% above %.2f%%", step.ErrorPercent, slo.ErrorPercent)
+	case step.Achieved < float64(step.TargetRate)*minAchievedRatio:
+		step.Reason = fmt.Sprintf("only %.0f of %d ops/s completed", step.Achieved, step.TargetRate)
+	default:
+		step.Sustainable = true
+	}
+}
+
+// MaxSustainable returns the highest sustainable step, or false if none was
+func (r *SaturationReport) MaxSustainable() (SaturationStep, bool) {
+	var best SaturationStep
+	found := false
+	for _, step := range r.Steps {
+		if step.Sustainable && (!found || step.Achieved > best.Achieved) {
+			best = step
+			found = true
+		}
+	}
+	return best, found
+}
+
+// Print prints the throughput/latency curve and the highest sustainable throughput
+func (r *SaturationReport) Print() {
+	fmt.Println("=================================================================")
+	fmt.Println("Saturation Search Report:")
+	fmt.Printf("  SLO: p99 <= %v, errors <= %.2f%%, at least %.0f%% of the target rate completed\n",
+		r.SLO.P99, r.SLO.ErrorPercent, minAchievedRatio*100)
+	fmt.Println("-----------------------------------------------------------------")
+	fmt.Printf("  %10s %12s %12s %12s %12s %8s  %s\n",
+		"Target/s", "Achieved/s", "P50", "P99 (worst)", "P99.9", "Errors", "Result")
+	for _, step := range r.Steps {
+		result := "ok"
+		if !step.Sustainable {
+			result = step.Reason
+		}
+		fmt.Printf("  %10d %12.2f %12v %12v %12v %7.2f%%  %s\n",
+			step.TargetRate, step.Achieved,
+			step.Latency.P50.Round(time.Microsecond), step.WorstP99.Round(time.Microsecond),
+			step.Latency.P999.Round(time.Microsecond), step.ErrorPercent, result)
+	}
+	fmt.Println("-----------------------------------------------------------------")
+	if best, ok := r.MaxSustainable(); ok {
+		fmt.Printf("  Max Sustainable Throughput: %.2f ops/s (target %d ops/s, p99 %v)\n",
+			best.Achieved, best.TargetRate, best.WorstP99.Round(time.Microsecond))
+	} else {
+		fmt.Println("  No step met the SLO")
+	}
+	fmt.Println("=================================================================")
+}
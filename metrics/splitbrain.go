@@ -0,0 +1,254 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// splitBrainResolution is the bucket width acknowledgements are grouped into.
+// Within a bucket the exact first/last acknowledgement of every server is kept,
+// so the resolution only bounds memory, not the precision of reported overlaps.
+const splitBrainResolution = 100 * time.Millisecond
+
+// splitBrainWindow is how soon a server must acknowledge again after another
+// server took over for both to count as accepting writes at once. Anything
+// slower is a failback, which takes far longer than this to carry out.
+const splitBrainWindow = 5 * time.Second
+
+// SplitBrainDetector tracks which servers acknowledged writes over time and
+// finds windows where more than one server was accepting writes at once.
+// Acknowledgement times should come from the server clock (clock_timestamp()),
+// so a write still in flight from a dying primary is placed when that primary
+// accepted it rather than when the client happened to read the reply.
+type SplitBrainDetector struct {
+	mu      sync.Mutex
+	buckets map[int64]map[string]*ackSpan // bucket -> server -> span
+	servers map[string]*ServerActivity
+}
+
+// ServerActivity summarizes all acknowledgements from one server
+type ServerActivity struct {
+	Server   string
+	FirstAck time.Time
+	LastAck  time.Time
+	Acks     int64
+}
+
+// OverlapWindow is an interval during which several servers acknowledged writes
+type OverlapWindow struct {
+	Start   time.Time
+	End     time.Time
+	Servers []string
+}
+
+// ackSpan is the first and last acknowledgement of one server within a bucket
+type ackSpan struct {
+	first time.Time
+	last  time.Time
+}
+
+// NewSplitBrainDetector creates an empty detector
+func NewSplitBrainDetector() *SplitBrainDetector {
+	return &SplitBrainDetector{
+		buckets: make(map[int64]map[string]*ackSpan),
+		servers: make(map[string]*ServerActivity),
+	}
+}
+
+// RecordAck records that server acknowledged a write at the given time
+func (d *SplitBrainDetector) RecordAck(server string, at time.Time) {
+	if server == "" || at.IsZero() {
+		return
+	}
+	bucket := at.UnixNano() / int64(splitBrainResolution)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	spans, ok := d.buckets[bucket]
+	if !ok {
+		spans = make(map[string]*ackSpan)
+		d.buckets[bucket] = spans
+	}
+	if span, ok := spans[server]; ok {
+		if at.Before(span.first) {
+			span.first = at
+		}
+		if at.After(span.last) {
+			span.last = at
+		}
+	} else {
+		spans[server] = &ackSpan{first: at, last: at}
+	}
+
+	activity, ok := d.servers[server]
+	if !ok {
+		activity = &ServerActivity{Server: server, FirstAck: at, LastAck: at}
+		d.servers[server] = activity
+	}
+	if at.Before(activity.FirstAck) {
+		activity.FirstAck = at
+	}
+	if at.After(activity.LastAck) {
+		activity.LastAck = at
+	}
+	activity.Acks++
+}
+
+// Servers returns every server that acknowledged writes, in order of first acknowledgement
+func (d *SplitBrainDetector) Servers() []ServerActivity {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	servers := make([]ServerActivity, 0, len(d.servers))
+	for _, activity := range d.servers {
+		servers = append(servers, *activity)
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].FirstAck.Before(servers[j].FirstAck) })
+	return servers
+}
+
+// Overlaps returns the windows in which two or more servers both acknowledged
+// writes. Acknowledgements are put in time order and grouped into runs from
+// the same server; a server acknowledging again within splitBrainWindow after
+// another server took over (A, B, A) was still accepting writes while the
+// other one was, which a clean failover (A, B) never shows. Each window runs
+// from the takeover to the interleaved acknowledgement; windows that touch are
+// merged.
+func (d *SplitBrainDetector) Overlaps() []OverlapWindow {
+	d.mu.Lock()
+	runs := d.runs()
+	d.mu.Unlock()
+
+	windows := make([]OverlapWindow, 0)
+	lastRun := make(map[string]int) // Latest earlier run of every server
+	for j, run := range runs {
+		i, ok := lastRun[run.server]
+		lastRun[run.server] = j
+		if !ok || run.first.Sub(runs[i+1].first) > splitBrainWindow {
+			continue
+		}
+
+		start, end := runs[i+1].first, run.first
+		servers := make([]string, 0, j-i)
+		for _, between := range runs[i : j+1] {
+			servers = append(servers, between.server)
+		}
+		servers = mergeServers(nil, servers)
+
+		if n := len(windows); n > 0 && !start.After(windows[n-1].End) {
+			w := &windows[n-1]
+			if end.After(w.End) {
+				w.End = end
+			}
+			w.Servers = mergeServers(w.Servers, servers)
+		} else {
+			windows = append(windows, OverlapWindow{Start: start, End: end, Servers: servers})
+		}
+	}
+	return windows
+}
+
+// ackRun is a sequence of acknowledgements from one server that no other
+// server acknowledged in between
+type ackRun struct {
+	server string
+	first  time.Time
+	last   time.Time
+}
+
+// runs returns the acknowledgements in time order, grouped into runs. The
+// first and last acknowledgement of every server in every bucket stand in for
+// all of its acknowledgements there. Must be called with mu held.
+func (d *SplitBrainDetector) runs() []ackRun {
+	type ack struct {
+		server string
+		at     time.Time
+	}
+	acks := make([]ack, 0, 2*len(d.buckets))
+	for _, spans := range d.buckets {
+		for server, span := range spans {
+			acks = append(acks, ack{server, span.first})
+			if span.last.After(span.first) {
+				acks = append(acks, ack{server, span.last})
+			}
+		}
+	}
+	sort.Slice(acks, func(i, j int) bool {
+		if !acks[i].at.Equal(acks[j].at) {
+			return acks[i].at.Before(acks[j].at)
+		}
+		return acks[i].server < acks[j].server
+	})
+
+	runs := make([]ackRun, 0)
+	for _, a := range acks {
+		if n := len(runs); n > 0 && runs[n-1].server == a.server {
+			runs[n-1].last = a.at
+			continue
+		}
+		runs = append(runs, ackRun{server: a.server, first: a.at, last: a.at})
+	}
+	return runs
+}
+
+// mergeServers returns the sorted union of two sorted server lists
+func mergeServers(a, b []string) []string {
+	seen := make(map[string]struct{}, len(a)+len(b))
+	merged := make([]string, 0, len(a)+len(b))
+	for _, name := range append(append([]string{}, a...), b...) {
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		merged = append(merged, name)
+	}
+	sort.Strings(merged)
+	return merged
+}
+
+// Print prints the servers that acknowledged writes and any split-brain windows
+func (d *SplitBrainDetector) Print() {
+	servers := d.Servers()
+	overlaps := d.Overlaps()
+
+	fmt.Println("=================================================================")
+	fmt.Println("Split-Brain Report:")
+	fmt.Println("-----------------------------------------------------------------")
+	fmt.Printf("  Servers that acknowledged writes: %d\n", len(servers))
+	for _, s := range servers {
+		fmt.Printf("  %s: %d acks from %s to %s\n", s.Server, s.Acks,
+			s.FirstAck.Format(time.RFC3339Nano), s.LastAck.Format(time.RFC3339Nano))
+	}
+	fmt.Println("-----------------------------------------------------------------")
+	if len(overlaps) == 0 {
+		fmt.Println("  No overlapping acknowledgements - at most one primary accepted writes at a time")
+	} else {
+		fmt.Printf("  ⚠️  SPLIT BRAIN: %d window(s) where multiple servers acknowledged writes\n", len(overlaps))
+		for _, w := range overlaps {
+			fmt.Printf("  %s to %s (%v): %s\n",
+				w.Start.Format(time.RFC3339Nano), w.End.Format(time.RFC3339Nano),
+				w.End.Sub(w.Start), strings.Join(w.Servers, ", "))
+		}
+	}
+	fmt.Println("=================================================================")
+}
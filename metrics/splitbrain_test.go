@@ -0,0 +1,98 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSplitBrainOverlaps(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ms := func(n int) time.Time { return base.Add(time.Duration(n) * time.Millisecond) }
+
+	type ack struct {
+		server string
+		at     int // Milliseconds after base
+	}
+	tests := []struct {
+		name string
+		acks []ack
+		want []OverlapWindow
+	}{
+		{
+			name: "single server",
+			acks: []ack{{"a", 0}, {"a", 50}, {"a", 150}},
+			want: []OverlapWindow{},
+		},
+		{
+			name: "clean failover inside one bucket",
+			acks: []ack{{"a", 10}, {"a", 40}, {"b", 60}, {"b", 90}},
+			want: []OverlapWindow{},
+		},
+		{
+			name: "clean failover across buckets",
+			acks: []ack{{"a", 10}, {"a", 150}, {"b", 2000}, {"b", 2100}},
+			want: []OverlapWindow{},
+		},
+		{
+			name: "failback after the window",
+			acks: []ack{{"a", 0}, {"b", 1000}, {"a", 1000 + 6000}},
+			want: []OverlapWindow{},
+		},
+		{
+			name: "interleaved inside one bucket",
+			acks: []ack{{"a", 10}, {"b", 20}, {"a", 30}},
+			want: []OverlapWindow{{Start: ms(20), End: ms(30), Servers: []string{"a", "b"}}},
+		},
+		{
+			name: "sparse acks alternating across buckets",
+			acks: []ack{{"a", 10}, {"b", 150}, {"a", 210}, {"b", 350}},
+			want: []OverlapWindow{{Start: ms(150), End: ms(350), Servers: []string{"a", "b"}}},
+		},
+		{
+			name: "sparse acks in every bucket",
+			acks: []ack{{"a", 10}, {"b", 50}, {"a", 110}, {"b", 150}, {"a", 210}},
+			want: []OverlapWindow{{Start: ms(50), End: ms(210), Servers: []string{"a", "b"}}},
+		},
+		{
+			name: "separate windows",
+			acks: []ack{{"a", 0}, {"b", 100}, {"a", 200}, {"a", 10000}, {"b", 20000}, {"a", 20500}},
+			want: []OverlapWindow{
+				{Start: ms(100), End: ms(200), Servers: []string{"a", "b"}},
+				{Start: ms(20000), End: ms(20500), Servers: []string{"a", "b"}},
+			},
+		},
+		{
+			name: "three servers",
+			acks: []ack{{"a", 0}, {"b", 100}, {"c", 200}, {"a", 300}},
+			want: []OverlapWindow{{Start: ms(100), End: ms(300), Servers: []string{"a", "b", "c"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewSplitBrainDetector()
+			for _, a := range tt.acks {
+				d.RecordAck(a.server, ms(a.at))
+			}
+			if got := d.Overlaps(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Overlaps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
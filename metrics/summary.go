@@ -0,0 +1,245 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// RunSummary is the machine-readable result of a load test run
+type RunSummary struct {
+	StartedAt       time.Time                 `json:"started_at"`
+	FinishedAt      time.Time                 `json:"finished_at"`
+	DurationSeconds float64                   `json:"duration_seconds"`
+	ServerVersion   string                    `json:"server_version,omitempty"`
+	Config          any                       `json:"config,omitempty"`
+	Operations      OperationsSummary         `json:"operations"`
+	Latency         map[string]LatencySummary `json:"latency"`
+	Errors          ErrorsSummary             `json:"errors"`
+	Retries         RetriesSummary            `json:"retries"`
+	DataLoss        *DataLoss                 `json:"data_loss"` // Null when the data loss check did not run
+	Passed          bool                      `json:"passed"`
+	Failures        []string                  `json:"failures"` // Failed assertions
+}
+
+// OperationsSummary counts the successful operations of a run
+type OperationsSummary struct {
+	Reads     int64            `json:"reads"`
+	Inserts   int64            `json:"inserts"`
+	Updates   int64            `json:"updates"`
+	Other     map[string]int64 `json:"other,omitempty"` // Operations other than reads, inserts and updates, by name
+	Total     int64            `json:"total"`
+	OpsPerSec float64          `json:"ops_per_sec"`
+	Bytes     int64            `json:"bytes"`
+}
+
+// ErrorsSummary breaks down the failed operations of a run
+type ErrorsSummary struct {
+	Total        int64            `json:"total"`
+	InDoubt      int64            `json:"in_doubt"`
+	ErrorPercent float64          `json:"error_percent"`
+	PerPool      map[string]int64 `json:"per_pool"`
+	ByKind       []ErrorKindCount `json:"by_kind"` // Most frequent first
+}
+
+// RetriesSummary counts the retried attempts of a run
+type RetriesSummary struct {
+	Total                  int64            `json:"total"`
+	FirstAttemptSuccesses  int64            `json:"first_attempt_successes"`
+	SucceededAfterRetrying int64            `json:"succeeded_after_retrying"`
+	Exhausted              int64            `json:"exhausted"`
+	ByKind                 []ErrorKindCount `json:"by_kind"` // Most frequent first
+}
+
+// ErrorKindCount is how often one kind of failure happened during the run
+type ErrorKindCount struct {
+	Op    string `json:"op"`
+	Phase string `json:"phase"`
+	Code  string `json:"code"`
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// LatencySummary is a latency distribution in milliseconds
+type LatencySummary struct {
+	Count  int64   `json:"count"`
+	AvgMs  float64 `json:"avg_ms"`
+	P50Ms  float64 `json:"p50_ms"`
+	P90Ms  float64 `json:"p90_ms"`
+	P95Ms  float64 `json:"p95_ms"`
+	P99Ms  float64 `json:"p99_ms"`
+	P999Ms float64 `json:"p999_ms"`
+	MaxMs  float64 `json:"max_ms"`
+}
+
+// Assertions are the pass/fail criteria of a run
+type Assertions struct {
+	MaxDataLoss     int64         // Most lost, corrupted or rolled-back rows allowed; negative disables
+	MaxP99          time.Duration // Highest p99 of any operation type; zero disables
+	MaxErrorPercent float64       // Highest share of failed operations; negative disables
+}
+
+// newLatencySummary converts a distribution to milliseconds
+func newLatencySummary(l LatencyStats) LatencySummary {
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	return LatencySummary{
+		Count:  l.Count,
+		AvgMs:  ms(l.Avg),
+		P50Ms:  ms(l.P50),
+		P90Ms:  ms(l.P90),
+		P95Ms:  ms(l.P95),
+		P99Ms:  ms(l.P99),
+		P999Ms: ms(l.P999),
+		MaxMs:  ms(l.Max),
+	}
+}
+
+// Summary builds the run summary from the final snapshot and, once it ran,
+// the outcome of data loss verification
+func (m *MetricsV2) Summary(final MetricsSnapshotV2) RunSummary {
+	summary := RunSummary{
+		StartedAt:       m.startTime,
+		FinishedAt:      m.startTime.Add(final.Duration),
+		DurationSeconds: final.Duration.Seconds(),
+		Operations: OperationsSummary{
+			Reads:   final.TotalReads,
+			Inserts: final.TotalInserts,
+			Updates: final.TotalUpdates,
+			Total:   final.TotalOperations,
+			Bytes:   final.TotalBytes,
+		},
+		Latency: make(map[string]LatencySummary),
+		Errors: ErrorsSummary{
+			Total:        final.TotalErrors,
+			InDoubt:      final.TotalInDoubt,
+			ErrorPercent: percentOf(final.TotalErrors, final.TotalOperations+final.TotalErrors),
+			PerPool:      make(map[string]int64),
+			ByKind:       errorKindCounts(final.Errors),
+		},
+		Retries: RetriesSummary{
+			Total:                  final.TotalRetries,
+			FirstAttemptSuccesses:  final.FirstAttemptSuccesses,
+			SucceededAfterRetrying: final.RetriedSuccesses,
+			Exhausted:              final.RetriesExhausted,
+			ByKind:                 errorKindCounts(final.Retries),
+		},
+		DataLoss: m.dataLoss.Load(),
+		Failures: make([]string, 0),
+	}
+	if final.Duration > 0 {
+		summary.Operations.OpsPerSec = float64(final.TotalOperations) / final.Duration.Seconds()
+	}
+
+	for op, stats := range map[string]LatencyStats{
+		"read":   final.ReadLatency,
+		"insert": final.InsertLatency,
+		"update": final.UpdateLatency,
+	} {
+		if stats.Count > 0 {
+			summary.Latency[op] = newLatencySummary(stats)
+		}
+	}
+	for _, op := range final.Operations {
+		if summary.Operations.Other == nil {
+			summary.Operations.Other = make(map[string]int64)
+		}
+		summary.Operations.Other[op.Name] = op.Total
+		if op.Latency.Count > 0 {
+			summary.Latency[op.Name] = newLatencySummary(op.Latency)
+		}
+	}
+	for _, pool := range final.Pools {
+		summary.Errors.PerPool[pool.Name] = pool.TotalErrors
+	}
+	return summary
+}
+
+// errorKindCounts converts whole-run error counts for the summary
+func errorKindCounts(counts []ErrorCount) []ErrorKindCount {
+	kinds := make([]ErrorKindCount, 0, len(counts))
+	for _, c := range counts {
+		kinds = append(kinds, ErrorKindCount{
+			Op:    c.Op,
+			Phase: c.Phase,
+			Code:  c.Code,
+			Name:  c.Name,
+			Count: c.Total,
+		})
+	}
+	return kinds
+}
+
+// Check evaluates the assertions against the summary and records the outcome in it
+func (a Assertions) Check(s *RunSummary) {
+	if a.MaxDataLoss >= 0 {
+		if s.DataLoss == nil {
+			s.Failures = append(s.Failures, "data loss was not verified")
+		} else if lost := s.DataLoss.Lost + s.DataLoss.Corrupted + s.DataLoss.LostUpdates; lost > a.MaxDataLoss {
+			s.Failures = append(s.Failures, fmt.Sprintf(
+				"%d rows lost, corrupted or rolled back (%d lost, %d corrupted, %d lost updates), at most %d allowed",
+				lost, s.DataLoss.Lost, s.DataLoss.Corrupted, s.DataLoss.LostUpdates, a.MaxDataLoss))
+		}
+	}
+	if a.MaxP99 > 0 {
+		maxMs := float64(a.MaxP99) / float64(time.Millisecond)
+		ops := make([]string, 0, len(s.Latency))
+		for op := range s.Latency {
+			ops = append(ops, op)
+		}
+		sort.Strings(ops)
+		for _, op := range ops {
+			if l := s.Latency[op]; l.P99Ms > maxMs {
+				s.Failures = append(s.Failures, fmt.Sprintf("%s p99 %.2fms above %.2fms", op, l.P99Ms, maxMs))
+			}
+		}
+	}
+	if a.MaxErrorPercent >= 0 && s.Errors.ErrorPercent > a.MaxErrorPercent {
+		s.Failures = append(s.Failures, fmt.Sprintf("error rate %.4f%% above %.4f%%", s.Errors.ErrorPercent, a.MaxErrorPercent))
+	}
+	s.Passed = len(s.Failures) == 0
+}
+
+// Write stores the summary as indented JSON at path
+func (s *RunSummary) Write(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode run summary: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write run summary: %w", err)
+	}
+	return nil
+}
+
+// Print prints the outcome of the assertions
+func (s *RunSummary) Print() {
+	fmt.Println("=================================================================")
+	fmt.Println("Assertions:")
+	fmt.Println("-----------------------------------------------------------------")
+	if s.Passed {
+		fmt.Println("  ✅ PASSED")
+	} else {
+		for _, failure := range s.Failures {
+			fmt.Printf("  ❌ %s\n", failure)
+		}
+	}
+	fmt.Println("=================================================================")
+}
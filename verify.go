@@ -0,0 +1,156 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/souravbiswassanto/high-write-load-client/clients/postgres"
+	"github.com/souravbiswassanto/high-write-load-client/config"
+	"github.com/souravbiswassanto/high-write-load-client/ledger"
+	"github.com/souravbiswassanto/high-write-load-client/metrics"
+)
+
+// runVerify implements the "verify" subcommand: it checks the IDs recorded in a
+// ledger against any PostgreSQL instance and returns the process exit code
+// (0 = all writes intact, 1 = verification failed, 2 = records missing,
+// corrupted or duplicated, acknowledged updates lost, or several servers
+// acknowledged writes at the same time).
+func runVerify(args []string) int {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	ledgerPath := fs.String("ledger", os.Getenv("LEDGER_PATH"), "Path to the acknowledged write ledger")
+	dsn := fs.String("dsn", "", "PostgreSQL connection string (defaults to the DB_* environment variables)")
+	table := fs.String("table", "", "Test table name (defaults to TABLE_NAME)")
+	timeout := fs.Duration("timeout", 30*time.Minute, "Maximum time to spend verifying")
+	showMissing := fs.Int("show-missing", 100, "Maximum number of missing ID ranges to print")
+	runID := fs.String("run", "", "Run to verify (defaults to the run the ledger was last appended to)")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	if *ledgerPath == "" {
+		fmt.Println("Error: -ledger is required")
+		return 1
+	}
+
+	if *dsn == "" || *table == "" {
+		cfg, err := config.LoadFromEnv()
+		if err != nil {
+			fmt.Printf("Error loading configuration: %v\n", err)
+			return 1
+		}
+		if *dsn == "" {
+			*dsn = cfg.DB.GetConnectionString()
+		}
+		if *table == "" {
+			*table = cfg.Workload.TableName
+		}
+	}
+
+	fmt.Println("=================================================================")
+	fmt.Println("PostgreSQL Data Loss Verification")
+	fmt.Println("=================================================================")
+	fmt.Printf("  Ledger: %s\n", *ledgerPath)
+	fmt.Printf("  Table: %s\n", *table)
+
+	entries, skipped, err := ledger.Read(*ledgerPath)
+	if err != nil {
+		fmt.Printf("Failed to read ledger: %v\n", err)
+		return 1
+	}
+	if skipped > 0 {
+		fmt.Printf("  Skipped %d unreadable ledger lines, e.g. a partial entry from a killed writer\n", skipped)
+	}
+	// A ledger kept across runs also holds earlier runs, whose rows may be
+	// gone or whose IDs a recreated table has reused
+	if *runID == "" {
+		*runID = ledger.LatestRun(entries)
+	}
+	run := ledger.ForRun(entries, *runID)
+	// Verifying nothing would report every write intact
+	if len(entries) == 0 {
+		fmt.Println("Ledger has no entries, nothing to verify")
+		return 1
+	}
+	if len(run) == 0 {
+		fmt.Printf("Ledger has no entries for run %q; runs in the ledger: %s\n", *runID, formatRuns(ledger.Runs(entries)))
+		return 1
+	}
+	if len(run) < len(entries) {
+		fmt.Printf("  Run: %s (%d of %d ledger entries; pass -run to verify another of %s)\n",
+			*runID, len(run), len(entries), formatRuns(ledger.Runs(entries)))
+	}
+	acked := postgres.AckedWritesFromLedger(entries, *runID)
+	fmt.Printf("  Ledger entries: %d (%d acknowledged inserts, %d updated rows, %d in-doubt batches)\n",
+		len(run), acked.TotalInserted(), len(acked.UpdatedVersions), len(acked.InDoubt))
+
+	db, err := sql.Open("postgres", *dsn)
+	if err != nil {
+		fmt.Printf("Failed to open database connection: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		fmt.Printf("Failed to ping database: %v\n", err)
+		return 1
+	}
+
+	result, err := postgres.NewVerifier(db, *table).Verify(ctx, acked)
+	if err != nil {
+		fmt.Printf("Verification failed: %v\n", err)
+		return 1
+	}
+
+	fmt.Println()
+	result.Print(*showMissing)
+
+	splitBrain := metrics.NewSplitBrainDetector()
+	for _, e := range run {
+		splitBrain.RecordAck(e.Server, e.ServerTime)
+	}
+	fmt.Println()
+	splitBrain.Print()
+
+	if result.LostRecords() > 0 || len(result.CorruptedIDs) > 0 || len(result.LostUpdates) > 0 ||
+		len(result.DuplicateKeys) > 0 || len(splitBrain.Overlaps()) > 0 {
+		return 2
+	}
+	return 0
+}
+
+// formatRuns lists run IDs for printing, naming entries written before run
+// IDs were recorded
+func formatRuns(runs []string) string {
+	names := make([]string, len(runs))
+	for i, run := range runs {
+		names[i] = run
+		if run == "" {
+			names[i] = "(untagged)"
+		}
+	}
+	return strings.Join(names, ", ")
+}